@@ -0,0 +1,106 @@
+package gin
+
+import (
+	"net/http"
+	"testing"
+)
+
+// mockWriter is a ResponseWriter which discards everything, so that the benchmarks
+// only measure the router.
+type mockWriter struct {
+	headers http.Header
+}
+
+func newMockWriter() *mockWriter {
+	return &mockWriter{headers: http.Header{}}
+}
+
+func (m *mockWriter) Header() http.Header {
+	return m.headers
+}
+
+func (m *mockWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (m *mockWriter) WriteString(s string) (int, error) {
+	return len(s), nil
+}
+
+func (m *mockWriter) WriteHeader(int) {}
+
+// benchRouter registers the routes of a small API for each of methods.
+func benchRouter(methods ...string) *Engine {
+	router := New()
+	for _, method := range methods {
+		router.Handle(method, "/", func(c *Context) {})
+		router.Handle(method, "/users", func(c *Context) {})
+		router.Handle(method, "/users/new", func(c *Context) {})
+		router.Handle(method, "/users/:id", func(c *Context) {})
+		router.Handle(method, "/users/:id/posts/:post", func(c *Context) {})
+		router.Handle(method, "/static/*filepath", func(c *Context) {})
+	}
+	return router
+}
+
+func runRequest(b *testing.B, r *Engine, method, path string) {
+	b.Helper()
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		panic(err)
+	}
+	w := newMockWriter()
+	// warm up the context pool and switch the engine to serving
+	r.ServeHTTP(w, req)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouteStatic(b *testing.B) {
+	runRequest(b, benchRouter(http.MethodGet), http.MethodGet, "/users/new")
+}
+
+func BenchmarkRouteParam(b *testing.B) {
+	runRequest(b, benchRouter(http.MethodGet), http.MethodGet, "/users/42/posts/7")
+}
+
+func BenchmarkRouteCatchAll(b *testing.B) {
+	runRequest(b, benchRouter(http.MethodGet), http.MethodGet, "/static/css/app.css")
+}
+
+func BenchmarkRouteCustomMethod(b *testing.B) {
+	router := benchRouter(http.MethodGet, http.MethodPost, "PURGE", "LINK")
+	runRequest(b, router, "PURGE", "/users/42")
+}
+
+func BenchmarkRouteMethodNotAllowed(b *testing.B) {
+	router := benchRouter(http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete)
+	router.HandleMethodNotAllowed = true
+	runRequest(b, router, http.MethodPatch, "/users/42/posts/7")
+}
+
+func BenchmarkRouteNotFound(b *testing.B) {
+	runRequest(b, benchRouter(http.MethodGet), http.MethodGet, "/missing/path")
+}
+
+// constraintRouter registers constrained params sharing a position with a static sibling.
+func constraintRouter() *Engine {
+	router := New()
+	router.GET("/items/:id{int}", func(c *Context) {})
+	router.GET("/items/:id{uuid}", func(c *Context) {})
+	router.GET("/items/latest", func(c *Context) {})
+	router.GET("/items/:slug{[a-z-]+}", func(c *Context) {})
+	return router
+}
+
+func BenchmarkRouteConstraint(b *testing.B) {
+	runRequest(b, constraintRouter(), http.MethodGet, "/items/1234567")
+}
+
+func BenchmarkRouteConstraintMismatch(b *testing.B) {
+	// rejected by {int} and {uuid}, matched by the regular expression
+	runRequest(b, constraintRouter(), http.MethodGet, "/items/hello-world")
+}
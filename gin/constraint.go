@@ -0,0 +1,201 @@
+package gin
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paramConstraint restricts the values a :param wildcard accepts, e.g. /users/:id{int}.
+// A value that does not satisfy the constraint does not match the route, so the
+// router falls through to the sibling routes or answers 404. Params with different
+// constraints may share a position, e.g. /at/:id{int} and /at/:id{uuid}, next to at
+// most one unconstrained param, e.g. /at/:slug, which is tried last.
+// 路径参数的约束，例如 :id{int}、:name{[a-z0-9-]+}，不满足约束的值不会命中该路由
+type paramConstraint struct {
+	// key is the name of the param without the constraint, e.g. "id" for :id{int}.
+	key string
+	// expr is the constraint as written in the route, e.g. "int" or "[a-z0-9-]+".
+	expr string
+	// match reports whether the raw value satisfies the constraint. It is called while
+	// routing, so it must not allocate.
+	match func(value string) bool
+	// convert returns the typed form of a matching value, see Param.Typed.
+	// It is nil for regular expression constraints.
+	convert func(value string) any
+}
+
+// namedConstraints are the built-in constraints, anything else is compiled as a regular expression.
+// Their match funcs check the values by hand, since the errors of strconv allocate.
+var namedConstraints = map[string]paramConstraint{
+	"int": {
+		match: func(value string) bool {
+			if value != "" && (value[0] == '-' || value[0] == '+') {
+				u, ok := parseDecimal(value[1:])
+				if value[0] == '-' {
+					return ok && u <= -math.MinInt
+				}
+				return ok && u <= math.MaxInt
+			}
+			u, ok := parseDecimal(value)
+			return ok && u <= math.MaxInt
+		},
+		convert: func(value string) any {
+			i, _ := strconv.Atoi(value)
+			return i
+		},
+	},
+	"uint": {
+		match: func(value string) bool {
+			u, ok := parseDecimal(value)
+			return ok && u <= math.MaxUint
+		},
+		convert: func(value string) any {
+			u, _ := strconv.ParseUint(value, 10, strconv.IntSize)
+			return uint(u)
+		},
+	},
+	"float": {
+		// decimal numbers only, e.g. 1.5 or -2e3, not inf, nan or hexadecimal
+		match: func(value string) bool {
+			if !isDecimalFloat(value) {
+				return false
+			}
+			// only an exponent out of range fails here
+			_, err := strconv.ParseFloat(value, 64)
+			return err == nil
+		},
+		convert: func(value string) any {
+			f, _ := strconv.ParseFloat(value, 64)
+			return f
+		},
+	},
+	"bool": {
+		match: func(value string) bool {
+			switch value {
+			case "1", "t", "T", "true", "TRUE", "True", "0", "f", "F", "false", "FALSE", "False":
+				return true
+			}
+			return false
+		},
+		convert: func(value string) any {
+			b, _ := strconv.ParseBool(value)
+			return b
+		},
+	},
+	"uuid": {
+		match: isUUID,
+		convert: func(value string) any {
+			return strings.ToLower(value)
+		},
+	},
+}
+
+// parseDecimal parses s, a non-empty run of ASCII digits, ok is false if it does not fit in an uint64.
+func parseDecimal(s string) (u uint64, ok bool) {
+	if s == "" {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		d := uint64(s[i] - '0')
+		if s[i] < '0' || s[i] > '9' || u > (math.MaxUint64-d)/10 {
+			return 0, false
+		}
+		u = u*10 + d
+	}
+	return u, true
+}
+
+// isDecimalFloat reports whether s is a decimal number with an optional fraction and exponent,
+// e.g. -1.5e3 or .5.
+func isDecimalFloat(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+// isUUID reports whether s is a UUID in its canonical form, e.g. 0b7e2c4a-6f0e-4d3c-9a1b-2c3d4e5f6a7b.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if ('0' > c || c > '9') && ('a' > c || c > 'f') && ('A' > c || c > 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitWildcard splits a wildcard such as ":id{int}" into its name and constraint expression.
+// The returned expression is empty if the wildcard has no constraint.
+func splitWildcard(wildcard, fullPath string) (name, expr string) {
+	name = wildcard[1:]
+	i := strings.IndexByte(name, '{')
+	if i < 0 {
+		return name, ""
+	}
+	if name[len(name)-1] != '}' || i == len(name)-2 {
+		panic("malformed constraint in wildcard '" + wildcard + "' in path '" + fullPath + "'")
+	}
+	return name[:i], name[i+1 : len(name)-1]
+}
+
+// newParamConstraint builds the constraint of wildcard, or returns nil if it has none.
+func newParamConstraint(wildcard, fullPath string) *paramConstraint {
+	key, expr := splitWildcard(wildcard, fullPath)
+	if expr == "" {
+		return nil
+	}
+	if wildcard[0] != ':' {
+		panic("constraints are only allowed on :param wildcards, has: '" +
+			wildcard + "' in path '" + fullPath + "'")
+	}
+
+	if named, ok := namedConstraints[expr]; ok {
+		named.key, named.expr = key, expr
+		return &named
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic("invalid constraint '" + expr + "' in path '" + fullPath + "': " + err.Error())
+	}
+	return &paramConstraint{key: key, expr: expr, match: re.MatchString}
+}
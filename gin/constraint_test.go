@@ -0,0 +1,76 @@
+package gin
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestParamConstraint(t *testing.T) {
+	tests := []struct {
+		expr  string
+		value string
+		match bool
+		typed any
+	}{
+		{"int", "42", true, 42},
+		{"int", "-7", true, -7},
+		{"int", "+7", true, 7},
+		{"int", "", false, nil},
+		{"int", "-", false, nil},
+		{"int", "4x", false, nil},
+		{"int", "1.5", false, nil},
+		{"int", strconv.Itoa(math.MaxInt), true, math.MaxInt},
+		{"int", strconv.Itoa(math.MinInt), true, math.MinInt},
+		{"int", strconv.FormatUint(math.MaxInt+1, 10), false, nil},
+		{"int", "99999999999999999999", false, nil},
+		{"uint", "42", true, uint(42)},
+		{"uint", "-1", false, nil},
+		{"uint", "+1", false, nil},
+		{"float", "1.5", true, 1.5},
+		{"float", "-2e3", true, -2000.0},
+		{"float", ".5", true, 0.5},
+		{"float", ".", false, nil},
+		{"float", "1e", false, nil},
+		{"float", "inf", false, nil},
+		{"float", "NaN", false, nil},
+		{"float", "0x1p3", false, nil},
+		{"bool", "true", true, true},
+		{"bool", "0", true, false},
+		{"bool", "yes", false, nil},
+		{"uuid", "0B7E2C4A-6F0E-4D3C-9A1B-2C3D4E5F6A7B", true, "0b7e2c4a-6f0e-4d3c-9a1b-2c3d4e5f6a7b"},
+		{"uuid", "0b7e2c4a6f0e-4d3c-9a1b-2c3d4e5f6a7b0", false, nil},
+		{"uuid", "0b7e2c4a-6f0e-4d3c-9a1b-2c3d4e5f6a7g", false, nil},
+		{"[a-z]+", "abc", true, nil},
+		{"[a-z]+", "abc1", false, nil},
+	}
+	for _, tt := range tests {
+		constraint := newParamConstraint(":p{"+tt.expr+"}", "/:p{"+tt.expr+"}")
+		if got := constraint.match(tt.value); got != tt.match {
+			t.Errorf("{%s} %q: got match %v, want %v", tt.expr, tt.value, got, tt.match)
+			continue
+		}
+		if allocs := testing.AllocsPerRun(10, func() { constraint.match(tt.value) }); allocs != 0 {
+			t.Errorf("{%s} %q: match allocates %v times", tt.expr, tt.value, allocs)
+		}
+		if !tt.match {
+			continue
+		}
+		if got := (Param{Value: tt.value, constraint: constraint}).Typed(); got != tt.typed {
+			t.Errorf("{%s} %q: got typed %#v, want %#v", tt.expr, tt.value, got, tt.typed)
+		}
+	}
+}
+
+func TestParamConstraintNoAllocs(t *testing.T) {
+	router := constraintRouter()
+	for _, path := range []string{"/items/1234567", "/items/hello-world", "/items/latest"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := newMockWriter()
+		router.ServeHTTP(w, req)
+		if allocs := testing.AllocsPerRun(10, func() { router.ServeHTTP(w, req) }); allocs != 0 {
+			t.Errorf("GET %s: %v allocations", path, allocs)
+		}
+	}
+}
@@ -3,12 +3,31 @@ package gin
 import (
 	"errors"
 	"fmt"
+	"gin2/gin/render"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
 )
 
+// Content-Type MIME of the formats Context.Negotiate can render.
+const (
+	MIMEJSON     = "application/json"
+	MIMEHTML     = "text/html"
+	MIMEXML      = "application/xml"
+	MIMEXML2     = "text/xml"
+	MIMEYAML     = "application/yaml"
+	MIMETOML     = "application/toml"
+	MIMEMSGPACK  = "application/msgpack"
+	MIMEPROTOBUF = "application/x-protobuf"
+)
+
+// negotiableFormats are the formats Context.Negotiate can render.
+var negotiableFormats = []string{MIMEJSON, MIMEHTML, MIMEXML, MIMEXML2, MIMEYAML, MIMETOML, MIMEMSGPACK, MIMEPROTOBUF}
+
 // abortIndex represents a typical value used in abort functions.
 const abortIndex int8 = math.MaxInt8 >> 1
 
@@ -27,6 +46,9 @@ type Context struct {
 	index    int8
 	fullPath string
 
+	// routeMeta is the metadata of the matched route, shared with the route table.
+	routeMeta map[string]any
+
 	// This mutex protects Keys map.
 	mu sync.RWMutex
 
@@ -62,12 +84,6 @@ type Context struct {
 	skippedNodes *[]skippedNode
 }
 
-// 设置json的数据
-func (c *Context) JSON(code int, obj any) {
-	// c.Render(code, render.JSON{Data: obj})
-	panic("unimplemented")
-}
-
 /************************************/
 /********** CONTEXT CREATION ********/
 /************************************/
@@ -79,6 +95,7 @@ func (c *Context) reset() {
 	c.index = -1
 
 	c.fullPath = ""
+	c.routeMeta = nil
 	c.Keys = nil
 	c.Errors = c.Errors[:0]
 	c.Accepted = nil
@@ -89,6 +106,17 @@ func (c *Context) reset() {
 	*c.skippedNodes = (*c.skippedNodes)[:0]
 }
 
+// ensureCapacity grows the params and skippedNodes buffers of a pooled context
+// allocated before routes with more params or sections were added.
+func (c *Context) ensureCapacity(maxParams, maxSections uint16) {
+	if cap(*c.params) < int(maxParams) {
+		*c.params = make(Params, 0, maxParams)
+	}
+	if cap(*c.skippedNodes) < int(maxSections) {
+		*c.skippedNodes = make([]skippedNode, 0, maxSections)
+	}
+}
+
 // Copy returns a copy of the current context that can be safely used outside the request's scope.
 // This has to be used when the context has to be passed to a goroutine.
 func (c *Context) Copy() *Context {
@@ -103,6 +131,7 @@ func (c *Context) Copy() *Context {
 	cp.index = abortIndex
 	cp.handlers = nil
 	cp.fullPath = c.fullPath
+	cp.routeMeta = c.routeMeta
 
 	cKeys := c.Keys
 	cp.Keys = make(map[any]any, len(cKeys))
@@ -122,27 +151,25 @@ func (c *Context) Copy() *Context {
 // HandlerName returns the main handler's name. For example if the handler is "handleGetUsers()",
 // this function will return "main.handleGetUsers".
 func (c *Context) HandlerName() string {
-	panic("unimplemented")
+	return nameOfFunction(c.handlers.Last())
 }
 
 // HandlerNames returns a list of all registered handlers for this context in descending order,
 // following the semantics of HandlerName()
 func (c *Context) HandlerNames() []string {
-	panic("unimplemented")
-	// hn := make([]string, 0, len(c.handlers))
-	// for _, val := range c.handlers {
-	// 	if val == nil {
-	// 		continue
-	// 	}
-	// 	hn = append(hn, nameOfFunction(val))
-	// }
-	// return hn
+	hn := make([]string, 0, len(c.handlers))
+	for _, val := range c.handlers {
+		if val == nil {
+			continue
+		}
+		hn = append(hn, nameOfFunction(val))
+	}
+	return hn
 }
 
 // Handler returns the main handler.
 func (c *Context) Handler() HandlerFunc {
-	panic("unimplemented")
-	// return c.handlers.Last()
+	return c.handlers.Last()
 }
 
 // FullPath returns a matched route full path. For not found routes
@@ -155,6 +182,23 @@ func (c *Context) FullPath() string {
 	return c.fullPath
 }
 
+// URLFor builds the URL of a named route, see Engine.URL.
+//
+//	u, err := c.URLFor("user.posts", "id", 42, "rest", "latest")
+func (c *Context) URLFor(name string, params ...any) (string, error) {
+	return c.engine.URL(name, params...)
+}
+
+// RouteMeta returns the metadata attached to the matched route with RouterGroup.Meta, ie: (value, true).
+// If the route has no such metadata it returns (nil, false)
+//
+//	router.GET("/admin", handler).Meta("scope", "admin")
+//	scope, _ := c.RouteMeta("scope") // "admin"
+func (c *Context) RouteMeta(key string) (value any, exists bool) {
+	value, exists = c.routeMeta[key]
+	return
+}
+
 /************************************/
 /*********** FLOW CONTROL ***********/
 /************************************/
@@ -188,19 +232,17 @@ func (c *Context) Abort() {
 // AbortWithStatus calls `Abort()` and writes the headers with the specified status code.
 // For example, a failed attempt to authenticate a request could use: context.AbortWithStatus(401).
 func (c *Context) AbortWithStatus(code int) {
-	panic("unimplemented")
-	// c.Status(code)
-	// c.Writer.WriteHeaderNow()
-	// c.Abort()
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
 }
 
 // AbortWithStatusJSON calls `Abort()` and then `JSON` internally.
 // This method stops the chain, writes the status code and return a JSON body.
 // It also sets the Content-Type as "application/json".
 func (c *Context) AbortWithStatusJSON(code int, jsonObj any) {
-	panic("unimplemented")
-	// c.Abort()
-	// c.JSON(code, jsonObj)
+	c.Abort()
+	c.JSON(code, jsonObj)
 }
 
 // AbortWithError calls `AbortWithStatus()` and `Error()` internally.
@@ -238,6 +280,298 @@ func (c *Context) Error(err error) *Error {
 	return parsedError
 }
 
+/************************************/
+/************ INPUT DATA ************/
+/************************************/
+
+// Param returns the value of the URL param.
+// It is a shortcut for c.Params.ByName(key).
+// The value of a catch-all param always starts with '/', it is "/" when the catch-all is empty.
+// The values come from Request.URL.Path, or from RawPath when Engine.UseRawPath is set,
+// unescaped if Engine.UnescapePathValues is set.
+//
+//	router.GET("/user/:id", func(c *gin.Context) {
+//	    // a GET request to /user/john
+//	    id := c.Param("id") // id == "john"
+//	})
+//	router.GET("/files/*filepath", func(c *gin.Context) {
+//	    // a GET request to /files/css/app.css
+//	    file := c.Param("filepath") // file == "/css/app.css"
+//	})
+func (c *Context) Param(key string) string {
+	return c.Params.ByName(key)
+}
+
+// AddParam adds param to context and
+// replaces path param key with given value for e2e testing purposes
+// Example Route: "/user/:id"
+// AddParam("id", 1)
+// Result: "/user/1"
+func (c *Context) AddParam(key, value string) {
+	c.Params = append(c.Params, Param{Key: key, Value: value})
+}
+
+// ParamInt returns the value of an int constrained URL param, as converted by the router.
+// It returns 0 if the param does not exist or is not constrained with {int}.
+//
+//	router.GET("/users/:id{int}", func(c *gin.Context) {
+//	    id := c.ParamInt("id") // no strconv.Atoi needed, /users/abc never gets here
+//	})
+func (c *Context) ParamInt(key string) int {
+	for _, p := range c.Params {
+		if p.Key == key {
+			i, _ := p.Typed().(int)
+			return i
+		}
+	}
+	return 0
+}
+
+// RemoteIP parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
+func (c *Context) RemoteIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return ""
+	}
+	return ip
+}
+
+/************************************/
+/******** RESPONSE RENDERING ********/
+/************************************/
+
+// Status sets the HTTP response code.
+func (c *Context) Status(code int) {
+	c.Writer.WriteHeader(code)
+}
+
+// Header is an intelligent shortcut for c.Writer.Header().Set(key, value).
+// It writes a header in the response.
+// If value == "", this method removes the header `c.Writer.Header().Del(key)`
+func (c *Context) Header(key, value string) {
+	if value == "" {
+		c.Writer.Header().Del(key)
+		return
+	}
+	c.Writer.Header().Set(key, value)
+}
+
+// bodyAllowedForStatus is a copy of http.bodyAllowedForStatus non-exported function.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// Render writes the response headers and calls render.Render to render data.
+// A render failure is pushed to c.Errors with ErrorTypeRender and aborts the chain.
+// If nothing was written yet, the status becomes 400 for render.ErrInvalidCallback,
+// which comes from the request, and 500 for the other errors.
+func (c *Context) Render(code int, r render.Render) {
+	c.Status(code)
+
+	if !bodyAllowedForStatus(code) {
+		r.WriteContentType(c.Writer)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	if err := r.Render(c.Writer); err != nil {
+		// 渲染失败时如果还没有写出响应，就把状态码改成 500（callback 非法时是 400），
+		// 错误记录到 c.Errors，由中间件统一处理
+		if !c.Writer.Written() {
+			status := http.StatusInternalServerError
+			if errors.Is(err, render.ErrInvalidCallback) {
+				status = http.StatusBadRequest
+			}
+			c.Status(status)
+		}
+		_ = c.Error(err).SetType(ErrorTypeRender)
+		c.Abort()
+	}
+}
+
+// IndentedJSON serializes the given struct as pretty JSON (indented + endlines) into the response body.
+// It also sets the Content-Type as "application/json".
+// WARNING: we recommend using this only for development purposes since printing pretty JSON is
+// more CPU and bandwidth consuming. Use Context.JSON() instead.
+func (c *Context) IndentedJSON(code int, obj any) {
+	c.Render(code, render.IndentedJSON{Data: obj})
+}
+
+// SecureJSON serializes the given struct as Secure JSON into the response body.
+// Default prepends "while(1);" to response body if the given struct is array values.
+// The prefix can be changed with Engine.SecureJsonPrefix.
+// It also sets the Content-Type as "application/json".
+func (c *Context) SecureJSON(code int, obj any) {
+	c.Render(code, render.SecureJSON{Prefix: c.engine.secureJSONPrefix, Data: obj})
+}
+
+// JSONP serializes the given struct as JSON into the response body.
+// It adds padding to response body to request data from a server residing in a different domain than the client.
+// The padding is the "callback" query value, which must be a JavaScript identifier or a dotted
+// path of identifiers, otherwise render.ErrInvalidCallback is pushed to c.Errors.
+// It also sets the Content-Type as "application/javascript".
+func (c *Context) JSONP(code int, obj any) {
+	callback := c.Request.URL.Query().Get("callback")
+	if callback == "" {
+		c.Render(code, render.JSON{Data: obj})
+		return
+	}
+	c.Render(code, render.JsonpJSON{Callback: callback, Data: obj})
+}
+
+// JSON serializes the given struct as JSON into the response body.
+// It also sets the Content-Type as "application/json".
+func (c *Context) JSON(code int, obj any) {
+	c.Render(code, render.JSON{Data: obj})
+}
+
+// AsciiJSON serializes the given struct as JSON into the response body with unicode to ASCII string.
+// It also sets the Content-Type as "application/json".
+func (c *Context) AsciiJSON(code int, obj any) {
+	c.Render(code, render.AsciiJSON{Data: obj})
+}
+
+// HTML renders the HTTP template specified by its file name.
+// It also updates the HTTP code and sets the Content-Type as "text/html".
+// The templates are loaded with Engine.LoadHTMLGlob, LoadHTMLFiles, LoadHTMLFS or SetHTMLTemplate.
+// See http://golang.org/doc/articles/wiki/
+func (c *Context) HTML(code int, name string, obj any) {
+	assert1(c.engine.HTMLRender != nil, "no HTML templates are loaded, see Engine.LoadHTMLGlob")
+	instance := c.engine.HTMLRender.Instance(name, obj)
+	c.Render(code, instance)
+}
+
+// PureJSON serializes the given struct as JSON into the response body.
+// PureJSON, unlike JSON, does not replace special html characters with their unicode entities.
+// It also sets the Content-Type as "application/json".
+func (c *Context) PureJSON(code int, obj any) {
+	c.Render(code, render.PureJSON{Data: obj})
+}
+
+// XML serializes the given struct as XML into the response body.
+// It also sets the Content-Type as "application/xml".
+func (c *Context) XML(code int, obj any) {
+	c.Render(code, render.XML{Data: obj})
+}
+
+// YAML serializes the given struct as YAML into the response body.
+// It also sets the Content-Type as "application/yaml".
+// It needs the yaml build tag, without it render.ErrCodecNotBuilt is pushed to c.Errors.
+func (c *Context) YAML(code int, obj any) {
+	c.Render(code, render.YAML{Data: obj})
+}
+
+// TOML serializes the given struct as TOML into the response body.
+// It also sets the Content-Type as "application/toml".
+// It needs the toml build tag, without it render.ErrCodecNotBuilt is pushed to c.Errors.
+func (c *Context) TOML(code int, obj any) {
+	c.Render(code, render.TOML{Data: obj})
+}
+
+// MsgPack serializes the given struct as MessagePack into the response body.
+// It also sets the Content-Type as "application/msgpack".
+// It needs the msgpack build tag, without it render.ErrCodecNotBuilt is pushed to c.Errors.
+func (c *Context) MsgPack(code int, obj any) {
+	c.Render(code, render.MsgPack{Data: obj})
+}
+
+// ProtoBuf serializes the given proto.Message as ProtoBuf into the response body.
+// It also sets the Content-Type as "application/x-protobuf".
+// It needs the protobuf build tag, without it render.ErrCodecNotBuilt is pushed to c.Errors.
+func (c *Context) ProtoBuf(code int, obj any) {
+	c.Render(code, render.ProtoBuf{Data: obj})
+}
+
+/************************************/
+/******** CONTENT NEGOTIATION *******/
+/************************************/
+
+// Negotiate contains all negotiations data.
+// Data is rendered for the formats without their own data, e.g. when JSONData is nil.
+type Negotiate struct {
+	Offered      []string
+	HTMLName     string
+	HTMLData     any
+	JSONData     any
+	XMLData      any
+	YAMLData     any
+	TOMLData     any
+	MsgPackData  any
+	ProtoBufData any
+	Data         any
+}
+
+// Negotiate calls different Render according to acceptable Accept format.
+// The response varies on Accept. If none of the offered formats is acceptable, the request
+// is aborted with 406 and a body listing the offered formats.
+//
+//	c.Negotiate(http.StatusOK, gin.Negotiate{
+//	    Offered:  []string{gin.MIMEJSON, gin.MIMEHTML},
+//	    HTMLName: "users/show",
+//	    Data:     user,
+//	})
+func (c *Context) Negotiate(code int, config Negotiate) {
+	for _, offer := range config.Offered {
+		assert1(slices.Contains(negotiableFormats, offer), "Negotiate can not render the offered format "+offer)
+	}
+
+	c.Writer.Header().Add("Vary", "Accept")
+	switch c.NegotiateFormat(config.Offered...) {
+	case MIMEJSON:
+		c.JSON(code, chooseData(config.JSONData, config.Data))
+	case MIMEHTML:
+		c.HTML(code, config.HTMLName, chooseData(config.HTMLData, config.Data))
+	case MIMEXML, MIMEXML2:
+		c.XML(code, chooseData(config.XMLData, config.Data))
+	case MIMEYAML:
+		c.YAML(code, chooseData(config.YAMLData, config.Data))
+	case MIMETOML:
+		c.TOML(code, chooseData(config.TOMLData, config.Data))
+	case MIMEMSGPACK:
+		c.MsgPack(code, chooseData(config.MsgPackData, config.Data))
+	case MIMEPROTOBUF:
+		c.ProtoBuf(code, chooseData(config.ProtoBufData, config.Data))
+	default:
+		// 没有可接受的格式，返回 406 并列出服务端支持的格式
+		offered := strings.Join(config.Offered, ", ")
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		_ = c.AbortWithError(http.StatusNotAcceptable, errors.New("the accepted formats are not offered by the server: "+offered))
+		_, _ = c.Writer.WriteString("Not Acceptable, available formats: " + offered + "\n")
+	}
+}
+
+// NegotiateFormat returns the offered format the client prefers, as RFC 9110 defines it:
+// each offer gets the q-value of the most specific media range of the Accept header matching it,
+// e.g. "text/html" over "text/*" over "*/*", and the offer with the highest q-value wins,
+// the first one on a tie. An offer with q=0 is not acceptable.
+// It returns the first offer if the request has no Accept header, "" if no offer is acceptable.
+// Context.Accepted, see SetAccepted, is used instead of the Accept header when it is set.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	assert1(len(offered) > 0, "you must provide at least one offer")
+
+	if c.Accepted == nil {
+		c.Accepted = parseAccept(c.Request.Header.Get("Accept"))
+	}
+	if len(c.Accepted) == 0 {
+		return offered[0]
+	}
+	return negotiateFormat(c.Accepted, offered)
+}
+
+// SetAccepted sets Accept header data.
+// The formats are media ranges, e.g. "application/json" or "text/*;q=0.5".
+func (c *Context) SetAccepted(formats ...string) {
+	c.Accepted = formats
+}
+
 /************************************/
 /******** METADATA MANAGEMENT********/
 /************************************/
@@ -0,0 +1,294 @@
+package gin
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gin2/gin/render"
+)
+
+func TestContextRender(t *testing.T) {
+	type item struct{ A int }
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("hello").Parse(`<p>{{.}}</p>`)))
+	router.GET("/json", func(c *Context) { c.JSON(http.StatusOK, H{"a": "<b>"}) })
+	router.GET("/indented", func(c *Context) { c.IndentedJSON(http.StatusOK, H{"a": 1}) })
+	router.GET("/secure", func(c *Context) { c.SecureJSON(http.StatusOK, []int{1, 2}) })
+	router.GET("/jsonp", func(c *Context) { c.JSONP(http.StatusOK, H{"a": 1}) })
+	router.GET("/ascii", func(c *Context) { c.AsciiJSON(http.StatusOK, H{"a": "é😀"}) })
+	router.GET("/pure", func(c *Context) { c.PureJSON(http.StatusOK, H{"a": "<b>"}) })
+	router.GET("/xml", func(c *Context) { c.XML(http.StatusOK, item{1}) })
+	router.GET("/html", func(c *Context) { c.HTML(http.StatusCreated, "hello", "world") })
+	router.GET("/nocontent", func(c *Context) { c.JSON(http.StatusNoContent, H{"a": 1}) })
+
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/json", http.StatusOK, "application/json; charset=utf-8", `{"a":"\u003cb\u003e"}`},
+		{"/indented", http.StatusOK, "application/json; charset=utf-8", "{\n    \"a\": 1\n}"},
+		{"/secure", http.StatusOK, "application/json; charset=utf-8", "while(1);[1,2]"},
+		{"/jsonp", http.StatusOK, "application/json; charset=utf-8", `{"a":1}`},
+		{"/jsonp?callback=app.cb", http.StatusOK, "application/javascript; charset=utf-8", `/**/app.cb({"a":1});`},
+		{"/ascii", http.StatusOK, "application/json", `{"a":"\u00e9\ud83d\ude00"}`},
+		{"/pure", http.StatusOK, "application/json; charset=utf-8", "{\"a\":\"<b>\"}\n"},
+		{"/xml", http.StatusOK, "application/xml; charset=utf-8", "<item><A>1</A></item>"},
+		{"/html", http.StatusCreated, "text/html; charset=utf-8", "<p>world</p>"},
+		{"/nocontent", http.StatusNoContent, "application/json; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("GET %s: got %d %q %q, want %d %q %q", tt.path,
+				w.Code, w.Header().Get("Content-Type"), w.Body.String(), tt.code, tt.contentType, tt.body)
+		}
+	}
+}
+
+func TestContextRenderFailure(t *testing.T) {
+	var renderErr error
+	router := New()
+	router.Use(func(c *Context) {
+		c.Next()
+		renderErr = nil
+		if last := c.Errors.ByType(ErrorTypeRender).Last(); last != nil {
+			renderErr = last.Err
+		}
+	})
+	router.GET("/json", func(c *Context) { c.JSON(http.StatusOK, func() {}) })
+	router.GET("/jsonp", func(c *Context) { c.JSONP(http.StatusOK, H{"a": 1}) })
+	router.GET("/yaml", func(c *Context) { c.YAML(http.StatusOK, H{"a": 1}) })
+	router.GET("/html", func(c *Context) { c.HTML(http.StatusOK, "missing", nil) })
+	router.SetHTMLTemplate(template.Must(template.New("hello").Parse(`<p>{{.}}</p>`)))
+
+	tests := []struct {
+		name string
+		path string
+		code int
+		err  error
+		// skip is set for the renderers which may succeed, depending on the build tags
+		skip bool
+	}{
+		{"unsupported value", "/json", http.StatusInternalServerError, nil, false},
+		{"invalid callback", "/jsonp?callback=alert(1)", http.StatusBadRequest, render.ErrInvalidCallback, false},
+		{"codec not built", "/yaml", http.StatusInternalServerError, render.ErrCodecNotBuilt,
+			render.YAML{Data: 1}.Render(httptest.NewRecorder()) == nil},
+		{"unknown template", "/html", http.StatusInternalServerError, nil, false},
+	}
+	for _, tt := range tests {
+		if tt.skip {
+			continue
+		}
+		w := performRequest(router, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+			t.Errorf("%s: got %d %q %q, want %d and no body", tt.name,
+				w.Code, w.Header().Get("Content-Type"), w.Body.String(), tt.code)
+		}
+		if renderErr == nil || tt.err != nil && !errors.Is(renderErr, tt.err) {
+			t.Errorf("%s: got error %v, want %v", tt.name, renderErr, tt.err)
+		}
+	}
+}
+
+func TestContextHTMLTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<body>{{block "content" .}}nothing here{{end}}</body>`)},
+		"users/show.html":   {Data: []byte(`{{define "content"}}<h1>{{.}}</h1>{{end}}`)},
+		"users/list.html":   {Data: []byte(`{{define "content"}}{{shout .}}{{end}}`)},
+	}
+	pages := &render.HTMLTemplates{}
+	pages.AddFromFS("users/show", fsys, "layouts/base.html", "users/show.html")
+	pages.AddFromFSFuncs("users/list", template.FuncMap{"shout": strings.ToUpper}, fsys, "layouts/base.html", "users/list.html")
+	pages.AddFromFS("empty", fsys, "layouts/base.html")
+
+	var renderErr error
+	router := New()
+	router.HTMLRender = pages
+	router.Use(func(c *Context) {
+		c.Next()
+		renderErr = nil
+		if last := c.Errors.Last(); last != nil {
+			renderErr = last.Err
+		}
+	})
+	router.GET("/:page", func(c *Context) {
+		c.HTML(http.StatusOK, strings.ReplaceAll(c.Param("page"), "-", "/"), "bob")
+	})
+
+	tests := []struct {
+		page string
+		code int
+		body string
+	}{
+		{"users-show", http.StatusOK, "<body><h1>bob</h1></body>"},
+		{"users-list", http.StatusOK, "<body>BOB</body>"},
+		{"empty", http.StatusOK, "<body>nothing here</body>"},
+		{"users-edit", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, "/"+tt.page)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("page %s: got %d %q, want %d %q", tt.page, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+	if !errors.Is(renderErr, render.ErrUnknownPage) {
+		t.Errorf("unknown page: got error %v, want %v", renderErr, render.ErrUnknownPage)
+	}
+
+	if recv := catchPanic(func() { pages.AddFromFS("empty", fsys, "users/show.html") }); recv != "HTML page 'empty' is already added" {
+		t.Errorf("page added twice: got panic %v", recv)
+	}
+}
+
+func TestContextNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		accepted []string
+		offered  []string
+		want     string
+	}{
+		{"no Accept header", "", nil, []string{MIMEXML, MIMEJSON}, MIMEXML},
+		{"exact", "application/json", nil, []string{MIMEXML, MIMEJSON}, MIMEJSON},
+		{"highest q-value", "application/xml;q=0.5, application/json;q=0.8", nil, []string{MIMEXML, MIMEJSON}, MIMEJSON},
+		{"q=0 excludes", "application/json;q=0, */*", nil, []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"q=0 excludes the only offer", "text/html;q=0, */*;q=0.1", nil, []string{MIMEHTML}, ""},
+		{"type over any", "*/*;q=0.1, text/*;q=0.9", nil, []string{MIMEJSON, MIMEHTML}, MIMEHTML},
+		{"exact over type", "text/*;q=0.9, text/html;q=0.2, */*;q=0.5", nil, []string{MIMEHTML, MIMEJSON}, MIMEJSON},
+		{"exact over any", "*/*, application/json;q=0", nil, []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"tie goes to the first offer", "application/json, application/xml", nil, []string{MIMEXML, MIMEJSON}, MIMEXML},
+		{"tie through a wildcard", "*/*", nil, []string{MIMEJSON, MIMEXML}, MIMEJSON},
+		{"case and spaces", " Application/JSON ; Q=0.5 ", nil, []string{MIMEJSON}, MIMEJSON},
+		{"none acceptable", "image/png", nil, []string{MIMEJSON, MIMEXML}, ""},
+		{"SetAccepted overrides the header", "application/json", []string{"text/*"}, []string{MIMEJSON, MIMEHTML}, MIMEHTML},
+		{"SetAccepted with q-values", "", []string{"application/json;q=0.1", "application/xml"}, []string{MIMEJSON, MIMEXML}, MIMEXML},
+	}
+	for _, tt := range tests {
+		router := New()
+		router.GET("/", func(c *Context) {
+			if tt.accepted != nil {
+				c.SetAccepted(tt.accepted...)
+			}
+			_, _ = c.Writer.WriteString(c.NegotiateFormat(tt.offered...))
+		})
+		var headers []header
+		if tt.accept != "" {
+			headers = append(headers, header{"Accept", tt.accept})
+		}
+		if got := performRequest(router, http.MethodGet, "/", headers...).Body.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContextNegotiate(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{
+			Offered:  []string{MIMEJSON, MIMEXML},
+			JSONData: H{"a": 1},
+			Data:     H{"a": 2},
+		})
+	})
+
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"application/json", http.StatusOK, "application/json; charset=utf-8", `{"a":1}`},
+		{"text/html", http.StatusNotAcceptable, "text/plain; charset=utf-8",
+			"Not Acceptable, available formats: application/json, application/xml\n"},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, "/", header{"Accept", tt.accept})
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("Accept %s: got %d %q %q, want %d %q %q", tt.accept,
+				w.Code, w.Header().Get("Content-Type"), w.Body.String(), tt.code, tt.contentType, tt.body)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Accept %s: got Vary %q, want %q", tt.accept, vary, "Accept")
+		}
+	}
+}
+
+func TestContextParams(t *testing.T) {
+	c := &Context{Params: Params{{Key: "id", Value: "1"}, {Key: "empty", Value: ""}}}
+	c.AddParam("id", "2")
+	c.AddParam("name", "bob")
+
+	tests := []struct {
+		key    string
+		value  string
+		exists bool
+	}{
+		{"id", "1", true},
+		{"empty", "", true},
+		{"name", "bob", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		if value, exists := c.Params.Get(tt.key); value != tt.value || exists != tt.exists {
+			t.Errorf("Get(%q): got %q %v, want %q %v", tt.key, value, exists, tt.value, tt.exists)
+		}
+		if value := c.Params.ByName(tt.key); value != tt.value {
+			t.Errorf("ByName(%q): got %q, want %q", tt.key, value, tt.value)
+		}
+		if value := c.Param(tt.key); value != tt.value {
+			t.Errorf("Param(%q): got %q, want %q", tt.key, value, tt.value)
+		}
+	}
+}
+
+func TestContextLoadHTML(t *testing.T) {
+	defer SetMode(TestMode)
+	loaders := []struct {
+		name string
+		load func(router *Engine, dir string)
+	}{
+		{"LoadHTMLGlob", func(router *Engine, dir string) { router.LoadHTMLGlob(filepath.Join(dir, "*.html")) }},
+		{"LoadHTMLFiles", func(router *Engine, dir string) { router.LoadHTMLFiles(filepath.Join(dir, "hello.html")) }},
+		{"LoadHTMLFS", func(router *Engine, dir string) { router.LoadHTMLFS(os.DirFS(dir), "*.html") }},
+	}
+	for _, mode := range []string{TestMode, DebugMode} {
+		for _, loader := range loaders {
+			SetMode(mode)
+			dir := t.TempDir()
+			file := filepath.Join(dir, "hello.html")
+			if err := os.WriteFile(file, []byte(`<p>[[shout .]]</p>`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			router := New()
+			router.Delims("[[", "]]")
+			router.FuncMap = template.FuncMap{"shout": strings.ToUpper}
+			loader.load(router, dir)
+			router.GET("/", func(c *Context) { c.HTML(http.StatusOK, "hello.html", "bob") })
+
+			if w := performRequest(router, http.MethodGet, "/"); w.Code != http.StatusOK || w.Body.String() != "<p>BOB</p>" {
+				t.Errorf("%s %s: got %d %q", mode, loader.name, w.Code, w.Body.String())
+			}
+
+			// only debug mode parses the changed file again
+			if err := os.WriteFile(file, []byte(`<div>[[shout .]]</div>`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			want := "<p>BOB</p>"
+			if mode == DebugMode {
+				want = "<div>BOB</div>"
+			}
+			if w := performRequest(router, http.MethodGet, "/"); w.Code != http.StatusOK || w.Body.String() != want {
+				t.Errorf("%s %s after the change: got %d %q, want %q", mode, loader.name, w.Code, w.Body.String(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package gin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig defines the config for CORS middleware.
+type CORSConfig struct {
+	// AllowOrigins is a list of origins a cross-domain request can be executed from.
+	// "*" allows all origins, and an origin may contain one "*" wildcard,
+	// e.g. "https://*.example.com".
+	// "*" can not be used with AllowCredentials, use AllowOriginFunc to accept any origin
+	// with credentials.
+	AllowOrigins []string
+
+	// AllowOriginFunc is a custom function to validate the origin, it is called
+	// when the origin is not matched by AllowOrigins.
+	// Optional.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods is a list of methods the client is allowed to use with cross-domain requests.
+	// Optional. Default value is GET, POST, PUT, PATCH, DELETE and HEAD.
+	AllowMethods []string
+
+	// AllowHeaders is a list of non-simple headers the client is allowed to use with cross-domain requests.
+	// Optional. By default the headers listed by Access-Control-Request-Headers are allowed.
+	AllowHeaders []string
+
+	// AllowCredentials indicates whether the request can include user credentials like
+	// cookies, HTTP authentication or client side SSL certificates.
+	// The allowed origin is then reflected in Access-Control-Allow-Origin.
+	AllowCredentials bool
+
+	// ExposeHeaders indicates which headers are safe to expose to the API of a CORS response.
+	// Optional.
+	ExposeHeaders []string
+
+	// MaxAge indicates how long the results of a preflight request can be cached.
+	// Optional. Zero means the header is not sent.
+	MaxAge time.Duration
+
+	// AllowPrivateNetwork answers the Private Network Access preflights, allowing public
+	// websites to request the server from a private network.
+	AllowPrivateNetwork bool
+}
+
+// corsOrigin is an allowed origin split around its "*" wildcard.
+type corsOrigin struct {
+	prefix   string
+	suffix   string
+	wildcard bool
+}
+
+func (o corsOrigin) match(origin string) bool {
+	if !o.wildcard {
+		return origin == o.prefix
+	}
+	return len(origin) > len(o.prefix)+len(o.suffix) &&
+		strings.HasPrefix(origin, o.prefix) && strings.HasSuffix(origin, o.suffix)
+}
+
+// CORS returns a middleware answering the CORS preflight requests and adding the
+// CORS headers to the actual responses, according to config.
+// Requests without an Origin header are not cross-origin and pass through untouched,
+// requests from an origin which is not allowed are aborted with 403.
+//
+//	router.Use(gin.CORS(gin.CORSConfig{
+//	    AllowOrigins:     []string{"https://*.example.com"},
+//	    AllowCredentials: true,
+//	    MaxAge:           12 * time.Hour,
+//	}))
+//
+// 跨域中间件：处理预检请求（OPTIONS），并给实际请求的响应加上 CORS 响应头
+func CORS(config CORSConfig) HandlerFunc {
+	assert1(len(config.AllowOrigins) > 0 || config.AllowOriginFunc != nil,
+		"CORS needs AllowOrigins or AllowOriginFunc")
+
+	allowAll := false
+	origins := make([]corsOrigin, 0, len(config.AllowOrigins))
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		prefix, suffix, wildcard := strings.Cut(strings.ToLower(origin), "*")
+		assert1(!strings.Contains(suffix, "*"), "only one wildcard is allowed in CORS origin '"+origin+"'")
+		origins = append(origins, corsOrigin{prefix: prefix, suffix: suffix, wildcard: wildcard})
+	}
+	// 带凭证时回显任意 Origin 等于关闭了同源保护，必须显式列出 Origin 或者使用 AllowOriginFunc
+	assert1(!allowAll || !config.AllowCredentials,
+		"CORS can not allow every origin with credentials, list the origins or use AllowOriginFunc")
+
+	allowMethods := config.AllowMethods
+	if len(allowMethods) == 0 {
+		allowMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodHead}
+	}
+	methods := strings.Join(allowMethods, ", ")
+	headers := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lower := strings.ToLower(origin)
+		for _, o := range origins {
+			if o.match(lower) {
+				return true
+			}
+		}
+		return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+	}
+
+	return func(c *Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		// The response depends on the Origin unless every origin gets "*"
+		if !allowAll {
+			header.Add("Vary", "Origin")
+		}
+		if !allowed(origin) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// "*" can not be used with credentials, the origin is reflected instead
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Preflight request
+		if c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != "" {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				header.Set("Access-Control-Allow-Headers", headers)
+			} else if requested := c.Request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+			if maxAge != "" {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			if config.AllowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+				header.Set("Access-Control-Allow-Private-Network", "true")
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		// Actual request
+		if exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,222 @@
+package gin
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newCORSRouter(config CORSConfig) *Engine {
+	router := New()
+	router.HandleOPTIONS = true
+	router.Use(CORS(config))
+	router.GET("/users", func(c *Context) { c.Status(http.StatusOK) })
+	router.POST("/users", func(c *Context) { c.Status(http.StatusCreated) })
+	return router
+}
+
+func TestCORS(t *testing.T) {
+	router := newCORSRouter(CORSConfig{
+		AllowOrigins:        []string{"https://example.com", "https://*.example.org"},
+		AllowHeaders:        []string{"Content-Type", "Authorization"},
+		ExposeHeaders:       []string{"X-Total"},
+		AllowCredentials:    true,
+		MaxAge:              12 * time.Hour,
+		AllowPrivateNetwork: true,
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		headers []header
+		code    int
+		want    map[string]string
+		vary    []string
+	}{
+		{
+			name:   "no origin",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:    "actual request",
+			method:  http.MethodGet,
+			headers: []header{{"Origin", "https://example.com"}},
+			code:    http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total",
+				"Access-Control-Max-Age":           "",
+			},
+			vary: []string{"Origin"},
+		},
+		{
+			name:    "wildcard subdomain",
+			method:  http.MethodPost,
+			headers: []header{{"Origin", "https://api.EXAMPLE.org"}},
+			code:    http.StatusCreated,
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://api.EXAMPLE.org"},
+			vary:    []string{"Origin"},
+		},
+		{
+			name:    "wildcard matches no empty subdomain",
+			method:  http.MethodGet,
+			headers: []header{{"Origin", "https://.example.org"}},
+			code:    http.StatusForbidden,
+			vary:    []string{"Origin"},
+		},
+		{
+			name:    "disallowed origin",
+			method:  http.MethodGet,
+			headers: []header{{"Origin", "https://evil.com"}},
+			code:    http.StatusForbidden,
+			want:    map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:    []string{"Origin"},
+		},
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			headers: []header{
+				{"Origin", "https://example.com"},
+				{"Access-Control-Request-Method", http.MethodPost},
+				{"Access-Control-Request-Headers", "X-Custom"},
+			},
+			code: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":          "https://example.com",
+				"Access-Control-Allow-Methods":         "GET, POST, PUT, PATCH, DELETE, HEAD",
+				"Access-Control-Allow-Headers":         "Content-Type, Authorization",
+				"Access-Control-Max-Age":               "43200",
+				"Access-Control-Expose-Headers":        "",
+				"Access-Control-Allow-Private-Network": "",
+			},
+			vary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "private network preflight",
+			method: http.MethodOptions,
+			headers: []header{
+				{"Origin", "https://example.com"},
+				{"Access-Control-Request-Method", http.MethodGet},
+				{"Access-Control-Request-Private-Network", "true"},
+			},
+			code: http.StatusNoContent,
+			want: map[string]string{"Access-Control-Allow-Private-Network": "true"},
+		},
+		{
+			name:   "preflight from a disallowed origin",
+			method: http.MethodOptions,
+			headers: []header{
+				{"Origin", "https://evil.com"},
+				{"Access-Control-Request-Method", http.MethodPost},
+			},
+			code: http.StatusForbidden,
+			want: map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performRequest(router, tt.method, "/users", tt.headers...)
+			if w.Code != tt.code {
+				t.Errorf("status: got %d, want %d", w.Code, tt.code)
+			}
+			for key, want := range tt.want {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s: got %q, want %q", key, got, want)
+				}
+			}
+			if tt.vary != nil && !slices.Equal(w.Header().Values("Vary"), tt.vary) {
+				t.Errorf("Vary: got %q, want %q", w.Header().Values("Vary"), tt.vary)
+			}
+		})
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	router := newCORSRouter(CORSConfig{AllowOrigins: []string{"*"}})
+
+	w := performRequest(router, http.MethodGet, "/users", header{"Origin", "https://any.com"})
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin: got %q, want %q", got, "*")
+	}
+	if vary := w.Header().Values("Vary"); len(vary) != 0 {
+		t.Errorf("Vary: got %q, want none", vary)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials: got %q, want none", got)
+	}
+}
+
+func TestCORSAllowOriginFunc(t *testing.T) {
+	router := newCORSRouter(CORSConfig{
+		AllowOriginFunc:  func(origin string) bool { return origin == "https://trusted.dev" },
+		AllowCredentials: true,
+	})
+
+	w := performRequest(router, http.MethodGet, "/users", header{"Origin", "https://trusted.dev"})
+	if got := w.Header().Get("Access-Control-Allow-Origin"); w.Code != http.StatusOK || got != "https://trusted.dev" {
+		t.Errorf("allowed origin: got %d %q", w.Code, got)
+	}
+	if w = performRequest(router, http.MethodGet, "/users", header{"Origin", "https://other.dev"}); w.Code != http.StatusForbidden {
+		t.Errorf("disallowed origin: got %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCORSInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CORSConfig
+		panic  string
+	}{
+		{"no origin", CORSConfig{}, "CORS needs AllowOrigins or AllowOriginFunc"},
+		{
+			"every origin with credentials",
+			CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true},
+			"CORS can not allow every origin with credentials, list the origins or use AllowOriginFunc",
+		},
+		{
+			"two wildcards",
+			CORSConfig{AllowOrigins: []string{"https://*.*.example.com"}},
+			"only one wildcard is allowed in CORS origin 'https://*.*.example.com'",
+		},
+	}
+	for _, tt := range tests {
+		recv := catchPanic(func() { CORS(tt.config) })
+		if recv != tt.panic {
+			t.Errorf("%s: got panic %v, want %q", tt.name, recv, tt.panic)
+		}
+	}
+}
+
+func TestHandleOPTIONS(t *testing.T) {
+	router := New()
+	router.HandleOPTIONS = true
+	router.GET("/users/:id", func(c *Context) {})
+	router.PUT("/users/:id", func(c *Context) {})
+	router.OPTIONS("/custom", func(c *Context) { c.Status(http.StatusTeapot) })
+
+	tests := []struct {
+		path  string
+		code  int
+		allow string
+	}{
+		{"/users/42", http.StatusNoContent, "GET, PUT, OPTIONS"},
+		{"/custom", http.StatusTeapot, ""},
+		{"/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodOptions, tt.path)
+		if w.Code != tt.code || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("OPTIONS %s: got %d %q, want %d %q", tt.path, w.Code, w.Header().Get("Allow"), tt.code, tt.allow)
+		}
+	}
+
+	router.HandleOPTIONS = false
+	if w := performRequest(router, http.MethodOptions, "/users/42"); w.Code != http.StatusNotFound {
+		t.Errorf("OPTIONS without HandleOPTIONS: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -2,6 +2,7 @@ package gin
 
 import (
 	"fmt"
+	"html/template"
 	"strings"
 	"sync/atomic"
 )
@@ -16,6 +17,34 @@ func IsDebugging() bool {
 // 可以声明和试用默认的DebugPrintFunc，
 var DebugPrintFunc func(format string, values ...any)
 
+// DebugPrintRouteFunc indicates debug log output format.
+// 注册路由时的输出格式，为 nil 时使用默认格式
+var DebugPrintRouteFunc func(httpMethod, absolutePath, handlerName string, nuHandlers int)
+
+func debugPrintRoute(httpMethod, absolutePath string, handlers HandlersChain) {
+	if IsDebugging() {
+		nuHandlers := len(handlers)
+		handlerName := nameOfFunction(handlers.Last())
+		if DebugPrintRouteFunc == nil {
+			debugPrint("%-6s %-25s --> %s (%d handlers)\n", httpMethod, absolutePath, handlerName, nuHandlers)
+		} else {
+			DebugPrintRouteFunc(httpMethod, absolutePath, handlerName, nuHandlers)
+		}
+	}
+}
+
+func debugPrintLoadTemplate(tmpl *template.Template) {
+	if IsDebugging() {
+		var buf strings.Builder
+		for _, t := range tmpl.Templates() {
+			buf.WriteString("\t- ")
+			buf.WriteString(t.Name())
+			buf.WriteString("\n")
+		}
+		debugPrint("Loaded HTML Templates (%d): \n%s\n", len(tmpl.Templates()), buf.String())
+	}
+}
+
 func debugPrint(format string, values ...any) {
 	if !IsDebugging() {
 		return
@@ -0,0 +1,275 @@
+package gin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OnlyFilesFS implements an http.FileSystem whose directories can not be listed:
+// a directory is only served through its index.html.
+//
+//	router.StaticFS("/assets", &gin.OnlyFilesFS{FileSystem: http.FS(assets)})
+type OnlyFilesFS struct {
+	FileSystem http.FileSystem
+}
+
+// Open conforms to http.FileSystem.
+func (o *OnlyFilesFS) Open(name string) (http.File, error) {
+	f, err := o.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return neutralizedReaddirFile{f}, nil
+}
+
+// neutralizedReaddirFile wraps http.File with a specific implementation of `Readdir`.
+type neutralizedReaddirFile struct {
+	http.File
+}
+
+// Readdir overrides the http.File default implementation.
+func (f neutralizedReaddirFile) Readdir(_ int) ([]fs.FileInfo, error) {
+	// this disables directory listing
+	return nil, nil
+}
+
+// Dir returns an http.FileSystem that can be used by http.FileServer(). It is used internally
+// in router.Static().
+// if listDirectory == true, then it works the same as http.Dir() otherwise it returns
+// a filesystem that prevents http.FileServer() to list the directory files.
+func Dir(root string, listDirectory bool) http.FileSystem {
+	fs := http.Dir(root)
+	if listDirectory {
+		return fs
+	}
+	return &OnlyFilesFS{FileSystem: fs}
+}
+
+// fileServer serves the files of an http.FileSystem for the static routes.
+// 静态文件服务：处理 HEAD、If-Modified-Since、ETag，拒绝路径穿越
+type fileServer struct {
+	fs http.FileSystem
+	// etags caches the ETags of the files without modification time, e.g. the files of
+	// an embed.FS, computed from their content. Such files are assumed to never change.
+	etags sync.Map
+}
+
+func newFileServer(fs http.FileSystem) *fileServer {
+	return &fileServer{fs: fs}
+}
+
+// serve writes the file name to c. It returns false if there is no such file, or if name
+// is a directory without index.html which can not be listed.
+func (s *fileServer) serve(c *Context, name string) bool {
+	if containsDotDot(name) {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return true
+	}
+	name = path.Clean("/" + name)
+
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	if fi.IsDir() {
+		index, err := s.fs.Open(path.Join(name, "index.html"))
+		hasIndex := err == nil
+		if hasIndex {
+			defer index.Close()
+		} else if _, noListing := s.fs.(*OnlyFilesFS); noListing {
+			return false
+		}
+
+		// Redirect to the canonical directory URL, so that relative links work
+		if p := c.Request.URL.Path; !strings.HasSuffix(p, "/") {
+			localRedirect(c, path.Base(p)+"/")
+			return true
+		}
+
+		if !hasIndex {
+			// Let net/http list the directory
+			r := new(http.Request)
+			*r = *c.Request
+			r.URL = new(url.URL)
+			*r.URL = *c.Request.URL
+			r.URL.Path = strings.TrimSuffix(name, "/") + "/"
+			r.URL.RawPath = ""
+			http.FileServer(s.fs).ServeHTTP(c.Writer, r)
+			return true
+		}
+		if fi, err = index.Stat(); err != nil || fi.IsDir() {
+			return false
+		}
+		name, f = path.Join(name, "index.html"), index
+	}
+
+	// Serve the precompressed sibling of the file, e.g. app.js.br, if the client accepts it
+	header := c.Writer.Header()
+	if sibling, siblingInfo, siblingName, coding := s.openPrecompressed(c, name); sibling != nil {
+		defer sibling.Close()
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", contentType(name, f))
+		}
+		header.Set("Content-Encoding", coding)
+		name, f, fi = siblingName, sibling, siblingInfo
+	}
+
+	if header.Get("ETag") == "" {
+		if etag := s.etag(name, f, fi); etag != "" {
+			header.Set("ETag", etag)
+		}
+	}
+	// ServeContent answers HEAD, Range, If-Modified-Since and If-None-Match
+	http.ServeContent(c.Writer, c.Request, fi.Name(), fi.ModTime(), f)
+	return true
+}
+
+// precompressedEncodings are the content codings of the precompressed siblings
+// of a file, e.g. app.js.br for app.js, in order of preference.
+var precompressedEncodings = []struct{ coding, ext string }{
+	{"br", ".br"},
+	{"zstd", ".zst"},
+	{"gzip", ".gz"},
+}
+
+// openPrecompressed opens the precompressed sibling of the file name with the coding the
+// request accepts with the highest q-value, or returns a nil file if there is none.
+// The response varies on Accept-Encoding as soon as name has a sibling.
+// 预压缩文件协商：按 Accept-Encoding 选择 .br/.zst/.gz 文件
+func (s *fileServer) openPrecompressed(c *Context, name string) (f http.File, fi fs.FileInfo, siblingName, coding string) {
+	accept := c.Request.Header.Get("Accept-Encoding")
+	var bestQ float64
+	vary := false
+	for _, enc := range precompressedEncodings {
+		sibling, err := s.fs.Open(name + enc.ext)
+		if err != nil {
+			continue
+		}
+		info, err := sibling.Stat()
+		if err != nil || info.IsDir() {
+			sibling.Close()
+			continue
+		}
+		vary = true
+		if q := encodingQuality(accept, enc.coding); q > bestQ {
+			if f != nil {
+				f.Close()
+			}
+			f, fi, siblingName, coding, bestQ = sibling, info, name+enc.ext, enc.coding, q
+		} else {
+			sibling.Close()
+		}
+	}
+	if vary {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+	}
+	return f, fi, siblingName, coding
+}
+
+// encodingQuality returns the q-value given to coding by the Accept-Encoding header value,
+// either directly or through "*", 0 if it is not acceptable.
+func encodingQuality(header, coding string) float64 {
+	wildcard := 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		name, _, q := parseWeighted(part)
+		if strings.EqualFold(name, coding) {
+			return q
+		}
+		if name == "*" {
+			wildcard = q
+		}
+	}
+	return wildcard
+}
+
+// acceptsHTML reports whether the Accept header value explicitly accepts text/html,
+// as the browsers do for navigations.
+func acceptsHTML(accept string) bool {
+	for _, part := range parseAccept(accept) {
+		if r, ok := parseMediaRange(part); ok && r.typ == "text" && r.subtype == "html" {
+			return r.q > 0
+		}
+	}
+	return false
+}
+
+// contentType returns the content type of the file name, from its extension or, as
+// net/http does, by sniffing its first bytes.
+func contentType(name string, f http.File) string {
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+	var buf [512]byte
+	n, _ := io.ReadFull(f, buf[:])
+	return http.DetectContentType(buf[:n])
+}
+
+// etag returns the ETag of the file name: a weak one made of its modification time and
+// size, or a strong one made of its content if it has no modification time.
+func (s *fileServer) etag(name string, f http.File, fi fs.FileInfo) string {
+	if !fi.ModTime().IsZero() {
+		return `W/"` + strconv.FormatInt(fi.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(fi.Size(), 16) + `"`
+	}
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etag)
+	return etag
+}
+
+// localRedirect gives a Moved Permanently response to the relative path newPath, keeping the query.
+func localRedirect(c *Context, newPath string) {
+	if q := c.Request.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	c.Writer.Header().Set("Location", newPath)
+	c.AbortWithStatus(http.StatusMovedPermanently)
+}
+
+// containsDotDot reports whether v has a ".." path element, a path traversal attempt.
+func containsDotDot(v string) bool {
+	if !strings.Contains(v, "..") {
+		return false
+	}
+	for _, ent := range strings.FieldsFunc(v, isSlashRune) {
+		if ent == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+func isSlashRune(r rune) bool { return r == '/' || r == '\\' }
+
+// serveNotFound answers c with the NoRoute handlers, including the SPA fallbacks,
+// the middleware of c already ran.
+func (engine *Engine) serveNotFound(c *Context) {
+	c.handlers = engine.allNoRoute[len(engine.Handlers):]
+	// Reset index
+	c.index = -1
+	serveError(c, http.StatusNotFound, default404Body)
+}
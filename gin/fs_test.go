@@ -0,0 +1,265 @@
+package gin
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+//go:embed testdata/assets
+var testAssets embed.FS
+
+func TestStatic(t *testing.T) {
+	router := New()
+	router.Static("/static", "testdata/assets")
+	router.StaticFS("/listed", Dir("testdata/assets", true))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		code    int
+		body    string
+		headers map[string]string
+	}{
+		{"file", http.MethodGet, "/static/app.js", http.StatusOK, "console.log(\"app\");\n", nil},
+		{"index of the root", http.MethodGet, "/static/", http.StatusOK, "<h1>home</h1>\n", nil},
+		{"directory listing is off", http.MethodGet, "/static/docs/", http.StatusNotFound, "404 page not found", nil},
+		{"directory without trailing slash", http.MethodGet, "/static/docs", http.StatusNotFound, "404 page not found", nil},
+		{"listed directory without trailing slash", http.MethodGet, "/listed/docs", http.StatusMovedPermanently, "",
+			map[string]string{"Location": "docs/"}},
+		{"missing file", http.MethodGet, "/static/missing.js", http.StatusNotFound, "404 page not found", nil},
+		{"dot dot", http.MethodGet, "/static/../fs.go", http.StatusBadRequest, "", nil},
+		{"escaped dot dot", http.MethodGet, "/static/docs/..%2f..%2ffs.go", http.StatusBadRequest, "", nil},
+		{"head", http.MethodHead, "/static/app.js", http.StatusOK, "",
+			map[string]string{"Content-Length": "20"}},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, tt.method, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		for key, want := range tt.headers {
+			if got := w.Header().Get(key); got != want {
+				t.Errorf("%s: %s is %q, want %q", tt.name, key, got, want)
+			}
+		}
+	}
+	if w := performRequest(router, http.MethodGet, "/listed/docs/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<a href="guide.txt">`) {
+		t.Errorf("directory listing: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticConditional(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.css")
+	if err := os.WriteFile(file, []byte("body{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	router := New()
+	router.Static("/static", dir)
+	w := performRequest(router, http.MethodGet, "/static/app.css")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || w.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+		t.Fatalf("GET: got %d, ETag %q, Last-Modified %q", w.Code, etag, w.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name   string
+		header header
+		code   int
+	}{
+		{"If-None-Match", header{"If-None-Match", etag}, http.StatusNotModified},
+		{"If-None-Match with another ETag", header{"If-None-Match", `W/"other"`}, http.StatusOK},
+		{"If-Modified-Since", header{"If-Modified-Since", modTime.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"If-Modified-Since before", header{"If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := performRequest(router, http.MethodGet, "/static/app.css", tt.header); w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.code)
+		}
+	}
+}
+
+func TestStaticFSEmbed(t *testing.T) {
+	assets, err := fs.Sub(testAssets, "testdata/assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := New()
+	router.StaticFS("/assets", &OnlyFilesFS{FileSystem: http.FS(assets)})
+
+	w := performRequest(router, http.MethodGet, "/assets/app.js")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "console.log(\"app\");\n" {
+		t.Fatalf("GET: got %d %q", w.Code, w.Body.String())
+	}
+	// the files of an embed.FS have no modification time, the ETag is made of the content
+	if !strings.HasPrefix(etag, `"`) {
+		t.Errorf("ETag: got %q, want a strong one", etag)
+	}
+	if w := performRequest(router, http.MethodGet, "/assets/app.js", header{"If-None-Match", etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: got %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if w := performRequest(router, http.MethodGet, "/assets/docs/"); w.Code != http.StatusNotFound {
+		t.Errorf("directory: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestStaticFile(t *testing.T) {
+	assets, err := fs.Sub(testAssets, "testdata/assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := New()
+	router.StaticFile("/app.js", "testdata/assets/app.js")
+	router.StaticFileFS("/guide", "docs/guide.txt", http.FS(assets))
+	router.StaticFile("/gone", "testdata/assets/gone.js")
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/app.js", http.StatusOK, "console.log(\"app\");\n"},
+		{http.MethodHead, "/app.js", http.StatusOK, ""},
+		{http.MethodGet, "/guide", http.StatusOK, "guide\n"},
+		{http.MethodGet, "/gone", http.StatusNotFound, "404 page not found"},
+		{http.MethodPost, "/app.js", http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, tt.method, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestStaticWildcardPath(t *testing.T) {
+	const hint = "; register a route with a handler calling http.ServeContent instead"
+	tests := []struct {
+		name     string
+		register func(r *Engine)
+		panic    string
+	}{
+		{"Static", func(r *Engine) { r.Static("/:lang/static", ".") },
+			"URL parameters can not be used when serving a static folder, has: '/:lang/static'" + hint},
+		{"StaticFS", func(r *Engine) { r.StaticFS("/files/*rest", http.Dir(".")) },
+			"URL parameters can not be used when serving a static folder, has: '/files/*rest'" + hint},
+		{"StaticFile", func(r *Engine) { r.StaticFile("/:name.ico", "favicon.ico") },
+			"URL parameters can not be used when serving a static file, has: '/:name.ico'" + hint},
+	}
+	for _, tt := range tests {
+		if recv := catchPanic(func() { tt.register(New()) }); recv != tt.panic {
+			t.Errorf("%s: got panic %v, want %q", tt.name, recv, tt.panic)
+		}
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":    {Data: []byte("plain")},
+		"app.js.br": {Data: []byte("brotli")},
+		"app.js.gz": {Data: []byte("gzip")},
+		"style.css": {Data: []byte("css")},
+		"notes":     {Data: []byte("notes")},
+		"notes.gz":  {Data: []byte("gzipped notes")},
+	}
+	router := New()
+	router.StaticFS("/assets", http.FS(fsys))
+	jsType := mime.TypeByExtension(".js")
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		body           string
+		coding         string
+		contentType    string
+		vary           string
+	}{
+		{"no Accept-Encoding", "/assets/app.js", "", "plain", "", jsType, "Accept-Encoding"},
+		{"preferred coding", "/assets/app.js", "gzip, br", "brotli", "br", jsType, "Accept-Encoding"},
+		{"highest q-value", "/assets/app.js", "br;q=0.5, gzip", "gzip", "gzip", jsType, "Accept-Encoding"},
+		{"wildcard", "/assets/app.js", "*;q=0.5", "brotli", "br", jsType, "Accept-Encoding"},
+		{"refused coding", "/assets/app.js", "br;q=0, gzip;q=0", "plain", "", jsType, "Accept-Encoding"},
+		{"sibling missing", "/assets/app.js", "zstd", "plain", "", jsType, "Accept-Encoding"},
+		{"no sibling", "/assets/style.css", "gzip, br", "css", "", mime.TypeByExtension(".css"), ""},
+		// the content type comes from the original file, sniffed when it has no known extension
+		{"sniffed content type", "/assets/notes", "gzip", "gzipped notes", "gzip", "text/plain; charset=utf-8", "Accept-Encoding"},
+	}
+	for _, tt := range tests {
+		var headers []header
+		if tt.acceptEncoding != "" {
+			headers = append(headers, header{"Accept-Encoding", tt.acceptEncoding})
+		}
+		w := performRequest(router, http.MethodGet, tt.path, headers...)
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.name, w.Code, w.Body.String(), tt.body)
+		}
+		got := [...]string{w.Header().Get("Content-Encoding"), w.Header().Get("Content-Type"), w.Header().Get("Vary")}
+		if want := [...]string{tt.coding, tt.contentType, tt.vary}; got != want {
+			t.Errorf("%s: Content-Encoding, Content-Type and Vary are %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":    {Data: []byte("<app>")},
+		"assets/app.js": {Data: []byte("js")},
+	}
+	router := New()
+	router.SPA("/app", http.FS(fsys), "index.html")
+	router.GET("/app/api/users", hostHandler("users"))
+	router.GET("/apple", hostHandler("apple"))
+
+	const navigation = "text/html,application/xhtml+xml,*/*;q=0.8"
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{"navigation", http.MethodGet, "/app/users/42", navigation, http.StatusOK, "<app>"},
+		{"navigation to the prefix", http.MethodGet, "/app", navigation, http.StatusOK, "<app>"},
+		{"prefix for an API client", http.MethodGet, "/app", "*/*", http.StatusNotFound, "404 page not found"},
+		{"head navigation", http.MethodHead, "/app/settings", navigation, http.StatusOK, ""},
+		{"any type", http.MethodGet, "/app/users/42", "*/*", http.StatusNotFound, "404 page not found"},
+		{"json", http.MethodGet, "/app/users/42", "application/json", http.StatusNotFound, "404 page not found"},
+		{"html refused", http.MethodGet, "/app/users/42", "text/html;q=0, */*", http.StatusNotFound, "404 page not found"},
+		{"post", http.MethodPost, "/app/users/42", navigation, http.StatusNotFound, "404 page not found"},
+		{"asset", http.MethodGet, "/app/assets/app.js", "*/*", http.StatusOK, "js"},
+		{"missing asset", http.MethodGet, "/app/assets/missing.js", "*/*", http.StatusNotFound, "404 page not found"},
+		{"route under the prefix", http.MethodGet, "/app/api/users", navigation, http.StatusOK, "users"},
+		{"path sharing the prefix", http.MethodGet, "/apple", navigation, http.StatusOK, "apple"},
+		{"unknown path sharing the prefix", http.MethodGet, "/apples", navigation, http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, tt.method, tt.path, header{"Accept", tt.accept})
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+
+	// without the trailing slash redirect, the prefix with a slash is the index of the root
+	router.RedirectTrailingSlash = false
+	if w := performRequest(router, http.MethodGet, "/app/", header{"Accept", navigation}); w.Code != http.StatusOK || w.Body.String() != "<app>" {
+		t.Errorf("navigation to the prefix with a slash: got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -1,14 +1,21 @@
 package gin
 
 import (
+	"gin2/gin/render"
 	"html/template"
+	"io/fs"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -23,6 +30,20 @@ const escapedColon = "\\:"
 const colon = ":"
 const backslash = "\\"
 
+var (
+	default404Body = []byte("404 page not found")
+	default405Body = []byte("405 method not allowed")
+
+	mimePlain = []string{"text/plain"}
+)
+
+var (
+	// regSafePrefix strips everything but letters, digits, '/' and '-' from X-Forwarded-Prefix
+	regSafePrefix = regexp.MustCompile("[^a-zA-Z0-9/-]+")
+	// regRemoveRepeatedChar collapses repeated slashes
+	regRemoveRepeatedChar = regexp.MustCompile("/{2,}")
+)
+
 type Engine struct {
 	RouterGroup
 
@@ -50,9 +71,16 @@ type Engine struct {
 	// If this is the case, the request is answered with 'Method Not Allowed'
 	// and HTTP status code 405.
 	// If no other Method is allowed, the request is delegated to the NotFound
-	// handler.
+	// handler. The Allow header of the response lists OPTIONS too when HandleOPTIONS is enabled.
 	HandleMethodNotAllowed bool
 
+	// HandleOPTIONS if enabled, the router automatically answers OPTIONS requests for the
+	// paths which have no OPTIONS route, with 204 and an Allow header listing the methods
+	// registered for the path. The global middleware still runs, so a CORS middleware
+	// attached with Use can answer the preflight requests.
+	// 自动应答 OPTIONS 请求，Allow 头中列出该路径已注册的方法
+	HandleOPTIONS bool
+
 	// ForwardedByClientIP if enabled, client IP will be parsed from the request's headers that
 	// match those stored at `(*gin.Engine).RemoteIPHeaders`. If no IP was
 	// fetched, it falls back to the IP obtained from
@@ -71,6 +99,7 @@ type Engine struct {
 	// UnescapePathValues if true, the path value will be unescaped.
 	// If UseRawPath is false (by default), the UnescapePathValues effectively is true,
 	// as url.Path gonna be used, which is already unescaped.
+	// The values are unescaped as paths, so a '+' stays a '+'.
 	UnescapePathValues bool
 
 	// RemoveExtraSlash a parameter can be parsed from the URL even with extra slashes.
@@ -103,21 +132,31 @@ type Engine struct {
 	// FuncMap is a map of functions that can be used in templates.
 	FuncMap template.FuncMap
 
+	// HTMLRender renders the templates of Context.HTML, see LoadHTMLGlob.
+	HTMLRender render.HTMLRender
+
 	allNoRoute  HandlersChain
 	allNoMethod HandlersChain
+	allOptions  HandlersChain
 	noRoute     HandlersChain
 	noMethod    HandlersChain
+	// spa are the fallbacks registered with RouterGroup.SPA, run before noRoute.
+	spa HandlersChain
 
-	pool sync.Pool
-
-	// trees is a slice of methodTree, each methodTree contains a method and its corresponding route tree.
-	// trees 是一个 methodTree 的切片，每个 methodTree 包含一个方法及其对应的路由树。
-	// 每个 methodTree 的 root 节点是一个 node 类型，表示路由树的根节点。
-	trees methodTrees
+	delims           render.Delims
+	secureJSONPrefix string
 
-	maxParams uint16
+	pool sync.Pool
 
-	maxSections uint16
+	// routes is the current route table, it is replaced as a whole when the routes change
+	// once the engine serves requests, see Engine.Reload.
+	routes atomic.Pointer[routeTable]
+	// routesMu serializes the writers of routes.
+	routesMu sync.Mutex
+	// serving is set when the engine handles its first request.
+	serving atomic.Bool
+	// reloading is set while the fn of Engine.Reload runs, which holds routesMu.
+	reloading atomic.Bool
 
 	//代理相关的内容
 	trustedProxies []string
@@ -135,6 +174,10 @@ type Engine struct {
 // 方便与其他基于net/http的中间件和工具集成
 // 不同框架可能在路由实现、中间件机制等方面有所差异，但核心的请求处理流程都是通过ServeHTTP方法实现的。
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !engine.serving.Load() {
+		engine.startServing()
+	}
+
 	//sync.Pool是Go语言中用于管理临时对象池的工具,这个是一个高性能的对象池实现，用于减少内存分配和垃圾回收的开销。
 	//现在对象池中存储的是*Context类型的对象，这个对象是Gin框架中用于处理HTTP请求的上下文对象。
 	c := engine.pool.Get().(*Context)
@@ -168,60 +211,192 @@ func (engine *Engine) handleHTTPRequest(c *Context) {
 		rPath = cleanPath(rPath)
 	}
 
-	// Find root of the tree for the given HTTP method
-	t := engine.trees
-	// Traverse all route trees and find the tree that matches the request method
-	for i, tl := 0, len(t); i < tl; i++ {
-		if t[i].method != httpMethod {
-			continue
+	// The request is routed with the same table until it ends, even if the routes are reloaded meanwhile
+	// 整个请求都使用同一个路由表快照，即使中途路由被重新加载
+	t := engine.routes.Load()
+	c.ensureCapacity(t.maxParams, t.maxSections)
+
+	// Routes registered for the request host take precedence over the default trees
+	// 先在匹配的域名路由树中查找，找不到再回退到默认的路由树
+	var host *hostTrees
+	if len(t.hosts) > 0 {
+		var hostName string
+		if host, hostName = engine.matchHost(c, t); host != nil {
+			if engine.serveTrees(c, &host.trees, host, hostName, httpMethod, rPath, unescape) {
+				return
+			}
+			*c.params = (*c.params)[:0]
+			*c.skippedNodes = (*c.skippedNodes)[:0]
+			c.Params = c.Params[:0]
 		}
-		root := t[i].root
-		// Find route in tree
-		value := root.getValue(rPath, c.params, c.skippedNodes, unescape)
-		if value.params != nil {
-			c.Params = *value.params
+	}
+
+	if engine.serveTrees(c, &t.trees, nil, "", httpMethod, rPath, unescape) {
+		return
+	}
+
+	if httpMethod == http.MethodOptions && engine.HandleOPTIONS {
+		var allowed []string
+		if host != nil {
+			allowed = engine.allowedMethods(c, &host.trees, allowed, httpMethod, rPath, unescape)
 		}
-		if value.handlers != nil {
-			c.handlers = value.handlers
-			c.fullPath = value.fullPath
+		allowed = engine.allowedMethods(c, &t.trees, allowed, httpMethod, rPath, unescape)
+		if len(allowed) > 0 {
+			c.handlers = engine.allOptions
+			c.writermem.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
 			c.Next()
 			c.writermem.WriteHeaderNow()
 			return
 		}
-		if httpMethod != http.MethodConnect && rPath != "/" {
-			// if value.tsr && engine.RedirectTrailingSlash {
-			// 	redirectTrailingSlash(c)
-			// 	return
-			// }
-			// if engine.RedirectFixedPath && redirectFixedPath(c, root, engine.RedirectFixedPath) {
-			// 	return
-			// }
+	}
+
+	if engine.HandleMethodNotAllowed && (len(t.trees.list) > 0 || host != nil) {
+		// According to RFC 9110 section 15.5.6, the origin server MUST generate an Allow header
+		// field in a 405 response containing a list of the target resource's currently supported methods.
+		var allowed []string
+		if host != nil {
+			allowed = engine.allowedMethods(c, &host.trees, allowed, httpMethod, rPath, unescape)
 		}
-		break
-	}
-
-	// if engine.HandleMethodNotAllowed && len(t) > 0 {
-	// 	// According to RFC 7231 section 6.5.5, MUST generate an Allow header field in response
-	// 	// containing a list of the target resource's currently supported methods.
-	// 	allowed := make([]string, 0, len(t)-1)
-	// 	for _, tree := range engine.trees {
-	// 		if tree.method == httpMethod {
-	// 			continue
-	// 		}
-	// 		if value := tree.root.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
-	// 			allowed = append(allowed, tree.method)
-	// 		}
-	// 	}
-	// 	if len(allowed) > 0 {
-	// 		c.handlers = engine.allNoMethod
-	// 		c.writermem.Header().Set("Allow", strings.Join(allowed, ", "))
-	// 		serveError(c, http.StatusMethodNotAllowed, default405Body)
-	// 		return
-	// 	}
-	// }
-	//
-	// c.handlers = engine.allNoRoute
-	// serveError(c, http.StatusNotFound, default404Body)
+		allowed = engine.allowedMethods(c, &t.trees, allowed, httpMethod, rPath, unescape)
+		if len(allowed) > 0 {
+			// HandleOPTIONS answers OPTIONS for the path as well
+			if engine.HandleOPTIONS && !slices.Contains(allowed, http.MethodOptions) {
+				allowed = append(allowed, http.MethodOptions)
+			}
+			c.handlers = engine.allNoMethod
+			c.writermem.Header().Set("Allow", strings.Join(allowed, ", "))
+			serveError(c, http.StatusMethodNotAllowed, default405Body)
+			return
+		}
+	}
+
+	c.handlers = engine.allNoRoute
+	serveError(c, http.StatusNotFound, default404Body)
+}
+
+// serveTrees looks the request up in the tree of its method and runs the matched handlers,
+// or redirects the request. It returns false if the request was not handled.
+// The values of the host wildcards, taken from hostName, are added to the params when host is not nil.
+func (engine *Engine) serveTrees(c *Context, t *methodTrees, host *hostTrees, hostName, httpMethod, rPath string, unescape bool) bool {
+	// Find the tree that matches the request method
+	root := t.get(httpMethod)
+	if root == nil {
+		return false
+	}
+
+	// Find route in tree
+	value := root.getValue(rPath, c.params, c.skippedNodes, unescape)
+	if value.params != nil {
+		c.Params = *value.params
+	}
+	if value.handlers != nil {
+		if host != nil && host.paramsCount > 0 {
+			host.match(hostName, c.params)
+			c.Params = *c.params
+		}
+		c.handlers = value.handlers
+		c.fullPath = value.fullPath
+		c.routeMeta = value.meta
+		c.Next()
+		c.writermem.WriteHeaderNow()
+		return true
+	}
+	if httpMethod != http.MethodConnect && rPath != "/" {
+		if value.tsr && engine.RedirectTrailingSlash {
+			redirectTrailingSlash(c)
+			return true
+		}
+		if engine.RedirectFixedPath && redirectFixedPath(c, root, engine.RedirectFixedPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMethods appends to allowed the methods, other than httpMethod, that have a route for rPath in t.
+// The server-wide OPTIONS request, "OPTIONS *", is allowed every method registered in t.
+func (engine *Engine) allowedMethods(c *Context, t *methodTrees, allowed []string, httpMethod, rPath string, unescape bool) []string {
+	for _, tree := range t.list {
+		if tree.method == httpMethod || slices.Contains(allowed, tree.method) {
+			continue
+		}
+		if rPath == "*" && httpMethod == http.MethodOptions {
+			allowed = append(allowed, tree.method)
+			continue
+		}
+		*c.skippedNodes = (*c.skippedNodes)[:0]
+		if value := tree.root.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
+			allowed = append(allowed, tree.method)
+		}
+	}
+	return allowed
+}
+
+// serveError runs the 404/405 handlers chain and writes the default body
+// only if none of the handlers wrote a response or changed the status code.
+// 先执行 NoRoute/NoMethod 的处理链（包含全局中间件），如果处理链已经写了响应，就不再写默认内容
+func serveError(c *Context, code int, defaultMessage []byte) {
+	c.writermem.status = code
+	c.Next()
+	if c.writermem.Written() {
+		return
+	}
+	if c.writermem.Status() == code {
+		c.writermem.Header()["Content-Type"] = mimePlain
+		_, err := c.Writer.Write(defaultMessage)
+		if err != nil {
+			debugPrint("cannot write message to writer during serve error: %v", err)
+		}
+		return
+	}
+	c.writermem.WriteHeaderNow()
+}
+
+// redirectTrailingSlash redirects /foo/ to /foo (or /foo to /foo/), honouring X-Forwarded-Prefix.
+// 尾部斜杠重定向，如果经过代理转发，需要把 X-Forwarded-Prefix 拼到 Location 前面
+func redirectTrailingSlash(c *Context) {
+	req := c.Request
+	p := req.URL.Path
+	if prefix := path.Clean(c.Request.Header.Get("X-Forwarded-Prefix")); prefix != "." {
+		prefix = regSafePrefix.ReplaceAllString(prefix, "")
+		prefix = regRemoveRepeatedChar.ReplaceAllString(prefix, "/")
+
+		p = strings.TrimSuffix(prefix, "/") + req.URL.Path
+	}
+	req.URL.Path = p + "/"
+	if length := len(p); length > 1 && p[length-1] == '/' {
+		req.URL.Path = p[:length-1]
+	}
+	redirectRequest(c)
+}
+
+// redirectFixedPath cleans the request path and redirects to the case-corrected route if one exists.
+func redirectFixedPath(c *Context, root *node, trailingSlash bool) bool {
+	req := c.Request
+	rPath := req.URL.Path
+
+	if fixedPath, ok := root.findCaseInsensitivePath(cleanPath(rPath), trailingSlash); ok {
+		req.URL.Path = string(fixedPath)
+		redirectRequest(c)
+		return true
+	}
+	return false
+}
+
+// redirectRequest answers with 301 for GET requests and 307 for all other methods,
+// so that the request body and method are preserved by the client.
+func redirectRequest(c *Context) {
+	req := c.Request
+	rPath := req.URL.Path
+	rURL := req.URL.String()
+
+	code := http.StatusMovedPermanently // Permanent redirect, request with GET method
+	if req.Method != http.MethodGet {
+		code = http.StatusTemporaryRedirect
+	}
+	debugPrint("redirecting request %d: %s --> %s", code, rPath, rURL)
+	http.Redirect(c.Writer, req, rURL, code)
+	c.writermem.WriteHeaderNow()
 }
 
 // OptionFunc defines the function to change the default configuration
@@ -244,17 +419,279 @@ func (engine *Engine) Use(middleware ...HandlerFunc) IRoutes {
 	engine.RouterGroup.Use(middleware...)
 	engine.rebuild404Handlers()
 	engine.rebuild405Handlers()
+	engine.rebuildOptionsHandlers()
 	return engine
 }
 
-func (engine *Engine) rebuild405Handlers() {
-	engine.allNoRoute = engine.combineHandlers(engine.noRoute)
+// updateRoutes applies fn to the route table. Until the engine serves its first request
+// the table is changed in place, afterwards fn changes a copy which then replaces the
+// table, so that the requests in flight are not affected.
+func (engine *Engine) updateRoutes(fn func(t *routeTable)) {
+	// routesMu is held by Reload, locking it again would hang
+	assert1(!engine.reloading.Load(),
+		"the routes of the engine can not be changed inside Reload, use the RouterGroup given to fn")
+	engine.routesMu.Lock()
+	defer engine.routesMu.Unlock()
+
+	t := engine.routes.Load()
+	if !engine.serving.Load() {
+		fn(t)
+		return
+	}
+	t = t.clone()
+	fn(t)
+	engine.routes.Store(t)
+}
+
+// startServing switches updateRoutes to copy-on-write, waiting for a route being registered.
+func (engine *Engine) startServing() {
+	engine.routesMu.Lock()
+	engine.serving.Store(true)
+	engine.routesMu.Unlock()
+}
+
+// Reload changes the routes at runtime. The routes registered through r, and the groups
+// created from it, are added to a copy of the route table which atomically replaces the
+// current one once fn returns. Requests in flight finish with the routes they started with,
+// and nothing is changed if fn panics.
+// r must not be used after fn returns. Changing the routes through the engine while fn runs,
+// e.g. with Engine.GET, a group of Engine.Host, RemoveRoute or Reload, panics instead of
+// waiting for fn, which would hang when fn itself does it.
+//
+//	router.Reload(func(r *gin.RouterGroup) {
+//	    plugins := r.Group("/plugins")
+//	    plugins.GET("/report", report)
+//	})
+//
+// 运行时热更新路由：在路由表的副本上注册，fn 返回后原子地替换当前路由表
+func (engine *Engine) Reload(fn func(r *RouterGroup)) {
+	assert1(!engine.reloading.Load(), "Reload can not be called inside Reload")
+	engine.routesMu.Lock()
+	defer engine.routesMu.Unlock()
+	engine.reloading.Store(true)
+	defer engine.reloading.Store(false)
+
+	t := engine.routes.Load().clone()
+	fn(&RouterGroup{
+		Handlers: engine.combineHandlers(nil),
+		basePath: engine.basePath,
+		engine:   engine,
+		table:    t,
+	})
+	if engine.serving.Load() {
+		t.updateRouteTrees()
+	}
+	t.sealed = true
+	engine.routes.Store(t)
+}
+
+// RemoveRoute removes the route registered for method and path, the path being the
+// pattern used at registration, e.g. "/users/:id". Requests in flight are not affected.
+// Routes registered through Engine.Host are not removed. It returns false if there is no such route.
+func (engine *Engine) RemoveRoute(method, path string) bool {
+	removed := false
+	engine.updateRoutes(func(t *routeTable) {
+		removed = t.removeRoute(method, path)
+	})
+	return removed
+}
+
+// Routes returns a slice of registered routes, including some useful information, such as:
+// the http method, path, and the handler name.
+// 遍历所有的 methodTree，把注册过的路由都列出来，可以在启动时打印或者比较不同版本的路由表
+func (engine *Engine) Routes() (routes RoutesInfo) {
+	t := engine.routes.Load()
+	for _, tree := range t.trees.list {
+		routes = iterate("", "", tree.method, routes, tree.root)
+	}
+	for _, host := range t.hosts {
+		for _, tree := range host.trees.list {
+			routes = iterate(host.pattern, "", tree.method, routes, tree.root)
+		}
+	}
+	return routes
+}
+
+// Lookup resolves path for method with the default route trees, as a request would be
+// routed, but without running any handler. path is escaped as in a request line, e.g.
+// "/files/a%2Fb", and honours UseRawPath and UnescapePathValues. It returns the full path
+// pattern of the matched route and its params, or whether a route exists for the path with
+// (without) a trailing slash when found is false. Lookup uses its own buffers, so it is safe
+// to call concurrently with the requests being served.
+//
+//	fullPath, params, _, found := router.Lookup(http.MethodGet, "/users/42/posts")
+//	// "/users/:id/posts", Params{{Key: "id", Value: "42"}}, true
+func (engine *Engine) Lookup(method, path string) (fullPath string, params Params, tsr bool, found bool) {
+	// Same as handleHTTPRequest, from the URL the request would have
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		return "", nil, false, false
+	}
+	rPath := u.Path
+	unescape := false
+	if engine.UseRawPath && len(u.RawPath) > 0 {
+		rPath = u.RawPath
+		unescape = engine.UnescapePathValues
+	}
+	if engine.RemoveExtraSlash {
+		rPath = cleanPath(rPath)
+	}
+
+	t := engine.routes.Load()
+	root := t.trees.get(method)
+	if root == nil {
+		return "", nil, false, false
+	}
+
+	params = make(Params, 0, t.maxParams)
+	skippedNodes := make([]skippedNode, 0, t.maxSections)
+	value := root.getValue(rPath, &params, &skippedNodes, unescape)
+	if value.handlers == nil {
+		return "", nil, value.tsr, false
+	}
+	return value.fullPath, params, false, true
+}
+
+func iterate(host, path, method string, routes RoutesInfo, root *node) RoutesInfo {
+	path += root.path
+	if len(root.handlers) > 0 {
+		handlerFunc := root.handlers.Last()
+		routes = append(routes, RouteInfo{
+			Host:        host,
+			Method:      method,
+			Path:        path,
+			Handler:     nameOfFunction(handlerFunc),
+			HandlerFunc: handlerFunc,
+			Meta:        root.meta,
+		})
+	}
+	for _, child := range root.children {
+		routes = iterate(host, path, method, routes, child)
+	}
+	return routes
+}
+
+// Delims sets template left and right delims and returns an Engine instance.
+func (engine *Engine) Delims(left, right string) *Engine {
+	engine.delims = render.Delims{Left: left, Right: right}
+	return engine
+}
+
+// LoadHTMLGlob loads HTML files identified by glob pattern
+// and associates the result with HTML renderer.
+// In debug mode the files are parsed again for each render.
+func (engine *Engine) LoadHTMLGlob(pattern string) {
+	templ := template.Must(engine.newTemplate().ParseGlob(pattern))
+
+	if IsDebugging() {
+		debugPrintLoadTemplate(templ)
+		engine.HTMLRender = render.HTMLDebug{Glob: pattern, FuncMap: engine.funcMap(), Delims: engine.delims}
+		return
+	}
+
+	engine.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLFiles loads a slice of HTML files
+// and associates the result with HTML renderer.
+// In debug mode the files are parsed again for each render.
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	templ := template.Must(engine.newTemplate().ParseFiles(files...))
+
+	if IsDebugging() {
+		debugPrintLoadTemplate(templ)
+		engine.HTMLRender = render.HTMLDebug{Files: files, FuncMap: engine.funcMap(), Delims: engine.delims}
+		return
+	}
+
+	engine.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLFS loads the files of fsys matching patterns, as fs.Glob does,
+// and associates the result with HTML renderer.
+// In debug mode the files are parsed again for each render.
+//
+//	//go:embed templates
+//	var templates embed.FS
+//
+//	router.LoadHTMLFS(templates, "templates/*.html")
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	templ := template.Must(engine.newTemplate().ParseFS(fsys, patterns...))
+
+	if IsDebugging() {
+		debugPrintLoadTemplate(templ)
+		engine.HTMLRender = render.HTMLDebug{FS: fsys, Patterns: patterns, FuncMap: engine.funcMap(), Delims: engine.delims}
+		return
+	}
+
+	engine.SetHTMLTemplate(templ)
+}
+
+// SetHTMLTemplate associate a template with HTML renderer.
+func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
+	engine.HTMLRender = render.HTMLProduction{Template: templ.Funcs(engine.funcMap())}
+}
+
+// HTMLTemplates associates HTML renderer with a template set per page, see render.HTMLTemplates.
+// The pages get the delims and the funcs of the engine, in debug mode they are parsed
+// again for each render.
+//
+//	pages := router.HTMLTemplates()
+//	pages.AddFromFiles("users/show", "layouts/base.html", "users/show.html")
+//
+//	router.GET("/users/:id", func(c *gin.Context) {
+//	    c.HTML(http.StatusOK, "users/show", user)
+//	})
+func (engine *Engine) HTMLTemplates() *render.HTMLTemplates {
+	templates := &render.HTMLTemplates{
+		FuncMap: engine.funcMap(),
+		Delims:  engine.delims,
+		Debug:   IsDebugging(),
+	}
+	engine.HTMLRender = templates
+	return templates
+}
+
+// newTemplate returns an empty template set with the delims and the funcs of the engine.
+func (engine *Engine) newTemplate() *template.Template {
+	return template.New("").Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.funcMap())
+}
+
+// SecureJsonPrefix sets the secureJSONPrefix used in Context.SecureJSON.
+func (engine *Engine) SecureJsonPrefix(prefix string) *Engine {
+	engine.secureJSONPrefix = prefix
+	return engine
+}
+
+// NoRoute adds handlers for NoRoute. It returns a 404 code by default.
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+	engine.noRoute = handlers
+	engine.rebuild404Handlers()
+}
+
+// NoMethod sets the handlers called when Engine.HandleMethodNotAllowed = true.
+func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
+	engine.noMethod = handlers
+	engine.rebuild405Handlers()
 }
 
 func (engine *Engine) rebuild404Handlers() {
+	engine.allNoRoute = engine.combineHandlers(slices.Concat(engine.spa, engine.noRoute))
+}
+
+func (engine *Engine) rebuild405Handlers() {
 	engine.allNoMethod = engine.combineHandlers(engine.noMethod)
 }
 
+func (engine *Engine) rebuildOptionsHandlers() {
+	engine.allOptions = engine.combineHandlers(HandlersChain{handleOptions})
+}
+
+// handleOptions answers the OPTIONS requests of Engine.HandleOPTIONS, the Allow header is already set.
+func handleOptions(c *Context) {
+	c.Status(http.StatusNoContent)
+}
+
 func (group *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain {
 	finalSize := len(group.Handlers) + len(handlers)
 	assert1(finalSize < int(abortIndex), "too many handlers")
@@ -264,6 +701,67 @@ func (group *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain
 	return mergedHandlers
 }
 
+// SetTrustedProxies set a list of network origins (IPv4 addresses,
+// IPv4 CIDRs, IPv6 addresses or IPv6 CIDRs) from which to trust
+// request's headers that contain alternative client IP or host
+// (e.g. X-Forwarded-For, X-Forwarded-Host).
+// No proxy is trusted by default, use Engine.SetTrustedProxies(nil) to
+// stop trusting the proxies set before.
+func (engine *Engine) SetTrustedProxies(trustedProxies []string) error {
+	engine.trustedProxies = trustedProxies
+	return engine.parseTrustedProxies()
+}
+
+// parseTrustedProxies parse Engine.trustedProxies to Engine.trustedCIDRs
+func (engine *Engine) parseTrustedProxies() error {
+	trustedCIDRs, err := engine.prepareTrustedCIDRs()
+	engine.trustedCIDRs = trustedCIDRs
+	return err
+}
+
+func (engine *Engine) prepareTrustedCIDRs() ([]*net.IPNet, error) {
+	if engine.trustedProxies == nil {
+		return nil, nil
+	}
+
+	cidr := make([]*net.IPNet, 0, len(engine.trustedProxies))
+	for _, trustedProxy := range engine.trustedProxies {
+		if !strings.Contains(trustedProxy, "/") {
+			ip := parseIP(trustedProxy)
+			if ip == nil {
+				return cidr, &net.ParseError{Type: "IP address", Text: trustedProxy}
+			}
+
+			switch len(ip) {
+			case net.IPv4len:
+				trustedProxy += "/32"
+			case net.IPv6len:
+				trustedProxy += "/128"
+			}
+		}
+		_, cidrNet, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			return cidr, err
+		}
+		cidr = append(cidr, cidrNet)
+	}
+	return cidr, nil
+}
+
+// parseIP parse a string representation of an IP and returns a net.IP with the
+// minimum byte representation or nil if input is invalid.
+func parseIP(ip string) net.IP {
+	parsedIP := net.ParseIP(ip)
+
+	if ipv4 := parsedIP.To4(); ipv4 != nil {
+		// return ip in a 4-byte representation
+		return ipv4
+	}
+
+	// return ip in a 16-byte representation or nil
+	return parsedIP
+}
+
 // isTrustedProxy will check whether the IP address is included in the trusted list according to Engine.trustedCIDRs
 func (engine *Engine) isTrustedProxy(ip net.IP) bool {
 	if engine.trustedCIDRs == nil {
@@ -295,13 +793,6 @@ func updateRouteTree(n *node) {
 	}
 }
 
-// updateRouteTrees do update to the route trees
-func (engine *Engine) updateRouteTrees() {
-	for _, tree := range engine.trees {
-		updateRouteTree(tree.root)
-	}
-}
-
 // Run attaches the router to a http.Server and starts listening and serving HTTP requests.
 // It is a shortcut for http.ListenAndServe(addr, router)
 // Note: this method will block the calling goroutine indefinitely unless an error happens.
@@ -312,7 +803,9 @@ func (engine *Engine) Run(addr ...string) (err error) {
 		debugPrint("[WARNING] You trusted all proxies, this is NOT safe. We recommend you to set a value.\n" +
 			"Please check https://github.com/gin-gonic/gin/blob/master/docs/doc.md#dont-trust-all-proxies for details.")
 	}
-	engine.updateRouteTrees()
+	engine.updateRoutes(func(t *routeTable) {
+		t.updateRouteTrees()
+	})
 	address := resolveAddress(addr)
 	debugPrint("Listening and serving HTTP on %s\n", address)
 	// 这里要求的是Engine的Handler方法,也就是Handler接口
@@ -369,26 +862,28 @@ func New(opts ...OptionFunc) *Engine {
 			basePath: "/",
 			root:     true,
 		},
+		RedirectTrailingSlash: true,
+		RedirectFixedPath:     false,
+		UnescapePathValues:    true,
+		delims:                render.Delims{Left: "{{", Right: "}}"},
+		secureJSONPrefix:      "while(1);",
 		// FuncMap:                template.FuncMap{},
-		// RedirectTrailingSlash:  true,
-		// RedirectFixedPath:      false,
 		// HandleMethodNotAllowed: false,
 		// ForwardedByClientIP:    true,
 		// RemoteIPHeaders:        []string{"X-Forwarded-For", "X-Real-IP"},
 		// TrustedPlatform:        defaultPlatform,
 		// UseRawPath:             false,
 		// RemoveExtraSlash:       false,
-		// UnescapePathValues:     true,
 		// MaxMultipartMemory:     defaultMultipartMemory,
-		// trees:                  make(methodTrees, 0, 9),
-		// delims:                 render.Delims{Left: "{{", Right: "}}"},
-		// secureJSONPrefix:       "while(1);",
 		// trustedProxies:         []string{"0.0.0.0/0", "::/0"},
 		// trustedCIDRs:           defaultTrustedCIDRs,
 	}
 	engine.engine = engine
+	engine.rebuildOptionsHandlers()
+	engine.routes.Store(&routeTable{})
 	engine.pool.New = func() any {
-		return engine.allocateContext(engine.maxParams)
+		t := engine.routes.Load()
+		return engine.allocateContext(t.maxParams, t.maxSections)
 	}
 	return engine.With(opts...)
 }
@@ -402,9 +897,9 @@ func (engine *Engine) With(opts ...OptionFunc) *Engine {
 	return engine
 }
 
-func (engine *Engine) allocateContext(maxParams uint16) *Context {
+func (engine *Engine) allocateContext(maxParams, maxSections uint16) *Context {
 	v := make(Params, 0, maxParams)
-	skippedNodes := make([]skippedNode, 0, engine.maxSections)
+	skippedNodes := make([]skippedNode, 0, maxSections)
 	return &Context{engine: engine, params: &v, skippedNodes: &skippedNodes}
 }
 
@@ -0,0 +1,126 @@
+package gin
+
+import (
+	"net"
+	"slices"
+	"strings"
+)
+
+// hostTrees holds the method trees of the routes registered for a host pattern.
+// 按域名路由，每个域名模式拥有自己独立的 methodTrees
+type hostTrees struct {
+	// pattern is the host pattern as registered, e.g. ":tenant.example.com".
+	pattern string
+	// labels is pattern split by '.', a label starting with ':' captures one label of the request host.
+	labels []string
+	// paramsCount is the number of ':' labels in pattern.
+	paramsCount uint16
+	trees       methodTrees
+}
+
+// Host returns a RouterGroup whose routes only match requests for the given host.
+// A label starting with ':' matches any single label of the request host and is added to
+// Context.Params, e.g. ":tenant.example.com" matches "acme.example.com" with tenant=acme.
+// Requests whose host matches no pattern, or whose path matches no route of the host,
+// are routed with the default trees.
+//
+//	admin := router.Host("admin.example.com")
+//	admin.GET("/", dashboard)
+//	tenants := router.Host(":tenant.example.com")
+//	tenants.GET("/", handler) // c.Params holds {Key: "tenant", Value: "acme"}
+func (engine *Engine) Host(pattern string) *RouterGroup {
+	return &RouterGroup{
+		Handlers: engine.combineHandlers(nil),
+		basePath: engine.basePath,
+		engine:   engine,
+		host:     newHostTrees(pattern).pattern,
+	}
+}
+
+// newHostTrees parses pattern and returns empty trees for it.
+func newHostTrees(pattern string) *hostTrees {
+	pattern = strings.ToLower(pattern)
+	h := &hostTrees{pattern: pattern, labels: strings.Split(pattern, ".")}
+	for _, label := range h.labels {
+		switch {
+		case label == "":
+			panic("empty label in host pattern '" + pattern + "'")
+		case label == ":":
+			panic("wildcards must be named with a non-empty name in host pattern '" + pattern + "'")
+		case label[0] == ':':
+			h.paramsCount++
+		case strings.ContainsAny(label, ":*/"):
+			panic("invalid label '" + label + "' in host pattern '" + pattern + "'")
+		}
+	}
+	return h
+}
+
+// hostTrees returns the trees registered for pattern, creating them on demand.
+// Exact patterns are kept in front of the wildcard ones so that they are matched first.
+func (t *routeTable) hostTrees(pattern string) *hostTrees {
+	for _, h := range t.hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+
+	h := newHostTrees(pattern)
+	if h.paramsCount == 0 {
+		i := 0
+		for i < len(t.hosts) && t.hosts[i].paramsCount == 0 {
+			i++
+		}
+		t.hosts = slices.Insert(t.hosts, i, h)
+	} else {
+		t.hosts = append(t.hosts, h)
+	}
+	return h
+}
+
+// match reports whether host matches the pattern. If params is not nil the
+// values of the ':' labels are appended to it.
+func (h *hostTrees) match(host string, params *Params) bool {
+	for i, label := range h.labels {
+		value, rest, found := strings.Cut(host, ".")
+		// the last label must consume the whole host and the others must not
+		if found == (i == len(h.labels)-1) {
+			return false
+		}
+		if label[0] == ':' {
+			if value == "" {
+				return false
+			}
+			if params != nil {
+				*params = append(*params, Param{Key: label[1:], Value: value})
+			}
+		} else if !strings.EqualFold(label, value) {
+			return false
+		}
+		host = rest
+	}
+	return true
+}
+
+// matchHost returns the hostTrees matching the request host and the host itself, or nil.
+// X-Forwarded-Host is only honoured when the request comes from a trusted proxy.
+func (engine *Engine) matchHost(c *Context, t *routeTable) (*hostTrees, string) {
+	host := c.Request.Host
+	if forwarded := c.Request.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil && engine.isTrustedProxy(ip) {
+			host, _, _ = strings.Cut(forwarded, ",")
+			host = strings.TrimSpace(host)
+		}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, h := range t.hosts {
+		if h.match(host, nil) {
+			return h, host
+		}
+	}
+	return nil, ""
+}
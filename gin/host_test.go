@@ -0,0 +1,129 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// hostHandler writes name followed by the params of the request.
+func hostHandler(name string) HandlerFunc {
+	return func(c *Context) {
+		var sb strings.Builder
+		sb.WriteString(name)
+		for _, p := range c.Params {
+			sb.WriteString(" " + p.Key + "=" + p.Value)
+		}
+		_, _ = c.Writer.WriteString(sb.String())
+	}
+}
+
+// performHostRequest sends a request for host to r, from remoteAddr if it is not empty.
+func performHostRequest(r http.Handler, method, host, path, remoteAddr string, headers ...header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = host
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	for _, h := range headers {
+		req.Header.Add(h.Key, h.Value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func newHostRouter(t *testing.T) *Engine {
+	t.Helper()
+	router := New()
+	if err := router.SetTrustedProxies([]string{"10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	// the wildcard pattern is registered first, the exact one must still win
+	tenants := router.Host(":tenant.example.com")
+	tenants.GET("/", hostHandler("tenant"))
+	tenants.GET("/users/:id", hostHandler("tenant user"))
+	tenants.POST("/items", hostHandler("tenant items"))
+	router.Host("admin.example.com").GET("/", hostHandler("admin"))
+	router.Host(":region.:tenant.example.org").GET("/", hostHandler("region"))
+	router.GET("/", hostHandler("default"))
+	router.GET("/about", hostHandler("about"))
+	router.GET("/items", hostHandler("items"))
+	return router
+}
+
+func TestHostRouting(t *testing.T) {
+	router := newHostRouter(t)
+
+	tests := []struct {
+		name       string
+		host       string
+		path       string
+		remoteAddr string
+		headers    []header
+		body       string
+	}{
+		{"exact over wildcard", "admin.example.com", "/", "", nil, "admin"},
+		{"wildcard", "acme.example.com", "/", "", nil, "tenant tenant=acme"},
+		{"host params after path params", "acme.example.com", "/users/42", "", nil, "tenant user id=42 tenant=acme"},
+		{"several host params", "eu.acme.example.org", "/", "", nil, "region region=eu tenant=acme"},
+		{"case insensitive", "ADMIN.Example.COM", "/", "", nil, "admin"},
+		{"port stripped", "acme.example.com:8080", "/", "", nil, "tenant tenant=acme"},
+		{"trailing dot stripped", "acme.example.com.", "/", "", nil, "tenant tenant=acme"},
+		{"port and trailing dot stripped", "admin.example.com.:443", "/", "", nil, "admin"},
+		{"wildcard takes one label", "a.b.example.com", "/", "", nil, "default"},
+		{"unknown host", "other.com", "/", "", nil, "default"},
+		{"path missing from the host trees", "acme.example.com", "/about", "", nil, "about"},
+		{"forwarded host from a trusted proxy", "proxy.local", "/", "10.0.0.1:1234",
+			[]header{{"X-Forwarded-Host", "admin.example.com, proxy.local"}}, "admin"},
+		{"forwarded host from an untrusted client", "proxy.local", "/", "192.0.2.1:1234",
+			[]header{{"X-Forwarded-Host", "admin.example.com"}}, "default"},
+	}
+	for _, tt := range tests {
+		w := performHostRequest(router, http.MethodGet, tt.host, tt.path, tt.remoteAddr, tt.headers...)
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.name, w.Code, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestHostMethodNotAllowed(t *testing.T) {
+	router := newHostRouter(t)
+	router.HandleMethodNotAllowed = true
+	router.HandleOPTIONS = true
+
+	tests := []struct {
+		method string
+		host   string
+		code   int
+		allow  string
+	}{
+		{http.MethodDelete, "acme.example.com", http.StatusMethodNotAllowed, "POST, GET, OPTIONS"},
+		{http.MethodDelete, "other.com", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodOptions, "acme.example.com", http.StatusNoContent, "POST, GET, OPTIONS"},
+		{http.MethodOptions, "other.com", http.StatusNoContent, "GET, OPTIONS"},
+	}
+	for _, tt := range tests {
+		w := performHostRequest(router, tt.method, tt.host, "/items", "")
+		if w.Code != tt.code || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.host, w.Code, w.Header().Get("Allow"), tt.code, tt.allow)
+		}
+	}
+}
+
+func TestHostInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		panic   string
+	}{
+		{"example..com", "empty label in host pattern 'example..com'"},
+		{":.example.com", "wildcards must be named with a non-empty name in host pattern ':.example.com'"},
+		{"a:b.example.com", "invalid label 'a:b' in host pattern 'a:b.example.com'"},
+	}
+	for _, tt := range tests {
+		if recv := catchPanic(func() { New().Host(tt.pattern) }); recv != tt.panic {
+			t.Errorf("Host(%q): got panic %v, want %q", tt.pattern, recv, tt.panic)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCodecNotBuilt is returned by the renderers whose codec was left out of the build,
+// see YAML, TOML, MsgPack and ProtoBuf.
+var ErrCodecNotBuilt = errors.New("render: codec not built in")
+
+// marshalFunc encodes v in the format of a renderer.
+type marshalFunc func(v any) ([]byte, error)
+
+// The codecs of the formats which need a third party package, each one is only set
+// when its build tag is set, e.g. `go build -tags yaml,msgpack`, so that the default
+// build does not depend on them.
+// 依赖第三方库的编码器，只有加上对应的 build tag 才会编译进来
+var (
+	marshalYAML     marshalFunc
+	marshalTOML     marshalFunc
+	marshalMsgPack  marshalFunc
+	marshalProtoBuf marshalFunc
+)
+
+// marshal encodes data with codec, or reports that the codec of format needs the build tag.
+func marshal(codec marshalFunc, format, tag string, data any) ([]byte, error) {
+	if codec == nil {
+		return nil, fmt.Errorf("%w: build with -tags %s to render %s", ErrCodecNotBuilt, tag, format)
+	}
+	return codec(data)
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+)
+
+// Delims represents a set of Left and Right delimiters for HTML template rendering.
+type Delims struct {
+	// Left delimiter, defaults to {{.
+	Left string
+	// Right delimiter, defaults to }}.
+	Right string
+}
+
+// HTMLRender interface is to be implemented by HTMLProduction and HTMLDebug.
+type HTMLRender interface {
+	// Instance returns an HTML instance.
+	Instance(string, any) Render
+}
+
+// HTMLProduction contains template reference, the delims are those the template was parsed with.
+type HTMLProduction struct {
+	Template *template.Template
+}
+
+// HTMLDebug contains template delims and pattern and function with file list.
+// The templates are parsed again for each render, so that the changes of the files
+// show up without restarting the program.
+// 调试模式下每次渲染都重新解析模板，修改模板文件后无需重启
+type HTMLDebug struct {
+	Files []string
+	Glob  string
+	FS    fs.FS
+	// Patterns are the glob patterns of the templates in FS.
+	Patterns []string
+	Delims   Delims
+	FuncMap  template.FuncMap
+}
+
+// HTML contains template reference and its name with given interface object.
+type HTML struct {
+	Template *template.Template
+	Name     string
+	Data     any
+}
+
+var htmlContentType = []string{"text/html; charset=utf-8"}
+
+// Instance (HTMLProduction) returns an HTML instance which it realizes Render interface.
+func (r HTMLProduction) Instance(name string, data any) Render {
+	return HTML{
+		Template: r.Template,
+		Name:     name,
+		Data:     data,
+	}
+}
+
+// Instance (HTMLDebug) returns an HTML instance which it realizes Render interface.
+// If the templates can not be parsed, the returned Render fails with the parse error.
+func (r HTMLDebug) Instance(name string, data any) Render {
+	templ, err := r.loadTemplate()
+	if err != nil {
+		return failedRender{err}
+	}
+	return HTML{
+		Template: templ,
+		Name:     name,
+		Data:     data,
+	}
+}
+
+func (r HTMLDebug) loadTemplate() (*template.Template, error) {
+	templ := template.New("").Delims(r.Delims.Left, r.Delims.Right).Funcs(r.FuncMap)
+	switch {
+	case len(r.Files) > 0:
+		return templ.ParseFiles(r.Files...)
+	case r.Glob != "":
+		return templ.ParseGlob(r.Glob)
+	case r.FS != nil:
+		return templ.ParseFS(r.FS, r.Patterns...)
+	}
+	panic("the HTML debug render was created without files, glob pattern or file system")
+}
+
+// Render (HTML) executes template and writes its result with custom ContentType for response.
+// Nothing is written if the template fails.
+func (r HTML) Render(w http.ResponseWriter) error {
+	var buf bytes.Buffer
+	var err error
+	if r.Name == "" {
+		err = r.Template.Execute(&buf, r.Data)
+	} else {
+		err = r.Template.ExecuteTemplate(&buf, r.Name, r.Data)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.WriteContentType(w)
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// WriteContentType (HTML) writes HTML ContentType.
+func (r HTML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
+
+// failedRender is a Render which only reports err, e.g. a template parse error.
+type failedRender struct {
+	err error
+}
+
+// Render (failedRender) returns the error without writing anything.
+func (r failedRender) Render(http.ResponseWriter) error {
+	return r.err
+}
+
+// WriteContentType (failedRender) writes nothing.
+func (r failedRender) WriteContentType(http.ResponseWriter) {}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"path/filepath"
+)
+
+// ErrUnknownPage is returned when HTMLTemplates renders a page which was not added.
+var ErrUnknownPage = errors.New("render: unknown HTML page")
+
+// HTMLTemplates is an HTMLRender with a template set per page, so that the pages do not
+// share the names of their templates. A page is made of a layout followed by its partials,
+// the layout is executed and the partials fill its blocks:
+//
+//	<!-- layouts/base.html -->
+//	<html><body>{{block "content" .}}nothing here{{end}}</body></html>
+//
+//	<!-- users/show.html -->
+//	{{define "content"}}<h1>{{.Name}}</h1>{{end}}
+//
+//	pages.AddFromFiles("users/show", "layouts/base.html", "users/show.html")
+//
+// 多模板集合：每个页面独立解析一套模板（布局 + 局部模板），页面之间的 define 名字互不冲突
+type HTMLTemplates struct {
+	// FuncMap are the funcs of every page, AddFromFilesFuncs adds or overrides funcs for a page.
+	FuncMap template.FuncMap
+	Delims  Delims
+	// Debug parses the files of a page again for each render.
+	Debug bool
+
+	pages map[string]*htmlPage
+}
+
+// htmlPage is the template set of a page and where it comes from.
+type htmlPage struct {
+	fsys    fs.FS
+	files   []string
+	funcMap template.FuncMap
+	templ   *template.Template
+}
+
+// AddFromFiles adds the page name made of files, the first one is the layout.
+// It panics if the files can not be parsed or the page was already added.
+func (r *HTMLTemplates) AddFromFiles(name string, files ...string) *template.Template {
+	return r.add(name, &htmlPage{files: files})
+}
+
+// AddFromFilesFuncs is like AddFromFiles, with funcMap added to HTMLTemplates.FuncMap for the page.
+func (r *HTMLTemplates) AddFromFilesFuncs(name string, funcMap template.FuncMap, files ...string) *template.Template {
+	return r.add(name, &htmlPage{files: files, funcMap: funcMap})
+}
+
+// AddFromFS is like AddFromFiles, with files read from fsys.
+func (r *HTMLTemplates) AddFromFS(name string, fsys fs.FS, files ...string) *template.Template {
+	return r.add(name, &htmlPage{fsys: fsys, files: files})
+}
+
+// AddFromFSFuncs is like AddFromFS, with funcMap added to HTMLTemplates.FuncMap for the page.
+func (r *HTMLTemplates) AddFromFSFuncs(name string, funcMap template.FuncMap, fsys fs.FS, files ...string) *template.Template {
+	return r.add(name, &htmlPage{fsys: fsys, files: files, funcMap: funcMap})
+}
+
+func (r *HTMLTemplates) add(name string, page *htmlPage) *template.Template {
+	if len(page.files) == 0 {
+		panic("HTML page '" + name + "' needs at least one file")
+	}
+	if _, ok := r.pages[name]; ok {
+		panic("HTML page '" + name + "' is already added")
+	}
+
+	page.templ = template.Must(r.parse(page))
+	if r.pages == nil {
+		r.pages = make(map[string]*htmlPage)
+	}
+	r.pages[name] = page
+	return page.templ
+}
+
+// parse returns the template set of page, named after its layout.
+func (r *HTMLTemplates) parse(page *htmlPage) (*template.Template, error) {
+	if page.fsys != nil {
+		return r.newTemplate(path.Base(page.files[0]), page).ParseFS(page.fsys, page.files...)
+	}
+	return r.newTemplate(filepath.Base(page.files[0]), page).ParseFiles(page.files...)
+}
+
+func (r *HTMLTemplates) newTemplate(name string, page *htmlPage) *template.Template {
+	return template.New(name).Delims(r.Delims.Left, r.Delims.Right).Funcs(r.FuncMap).Funcs(page.funcMap)
+}
+
+// Instance (HTMLTemplates) returns an HTML instance executing the layout of the page name.
+// If there is no such page, the returned Render fails with ErrUnknownPage.
+func (r *HTMLTemplates) Instance(name string, data any) Render {
+	page, ok := r.pages[name]
+	if !ok {
+		return failedRender{fmt.Errorf("%w %q", ErrUnknownPage, name)}
+	}
+
+	templ := page.templ
+	if r.Debug {
+		var err error
+		if templ, err = r.parse(page); err != nil {
+			return failedRender{err}
+		}
+	}
+	return HTML{
+		Template: templ,
+		Data:     data,
+	}
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// JSON contains the given interface object.
+type JSON struct {
+	Data any
+}
+
+// IndentedJSON contains the given interface object.
+type IndentedJSON struct {
+	Data any
+}
+
+// SecureJSON contains the given interface object and its prefix.
+type SecureJSON struct {
+	Prefix string
+	Data   any
+}
+
+// JsonpJSON contains the given interface object and its callback.
+type JsonpJSON struct {
+	Callback string
+	Data     any
+}
+
+// AsciiJSON contains the given interface object.
+type AsciiJSON struct {
+	Data any
+}
+
+// PureJSON contains the given interface object.
+type PureJSON struct {
+	Data any
+}
+
+var (
+	jsonContentType      = []string{"application/json; charset=utf-8"}
+	jsonpContentType     = []string{"application/javascript; charset=utf-8"}
+	jsonASCIIContentType = []string{"application/json"}
+)
+
+// ErrInvalidCallback is returned by JsonpJSON when its callback is not a JavaScript
+// identifier or a dotted path of identifiers, e.g. "jQuery.cb".
+var ErrInvalidCallback = errors.New("render: invalid JSONP callback")
+
+// jsonpCallback matches the callbacks that can not inject code into the response.
+// 只允许标识符或者用 '.' 连接的标识符，防止通过 callback 注入脚本
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+// Render (JSON) writes data with custom ContentType.
+func (r JSON) Render(w http.ResponseWriter) error {
+	return WriteJSON(w, r.Data)
+}
+
+// WriteContentType (JSON) writes JSON ContentType.
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// WriteJSON marshals the given interface object and writes it with custom ContentType.
+// Nothing is written if the object can not be marshaled.
+func WriteJSON(w http.ResponseWriter, obj any) error {
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	writeContentType(w, jsonContentType)
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// Render (IndentedJSON) marshals the given interface object and writes it with custom ContentType.
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	jsonBytes, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// WriteContentType (IndentedJSON) writes JSON ContentType.
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render (SecureJSON) marshals the given interface object and writes it with custom ContentType.
+// The prefix is only written before arrays, the JSON values which can be hijacked
+// by redefining the Array constructor.
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	jsonBytes, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	if bytes.HasPrefix(jsonBytes, []byte("[")) && bytes.HasSuffix(jsonBytes, []byte("]")) {
+		if _, err = w.Write([]byte(r.Prefix)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// WriteContentType (SecureJSON) writes JSON ContentType.
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render (JsonpJSON) marshals the given interface object and writes it and its callback with custom ContentType.
+// It returns ErrInvalidCallback, and writes nothing, if the callback is not a valid identifier.
+func (r JsonpJSON) Render(w http.ResponseWriter) error {
+	if r.Callback != "" && !jsonpCallback.MatchString(r.Callback) {
+		return ErrInvalidCallback
+	}
+	jsonBytes, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+
+	if r.Callback == "" {
+		_, err = w.Write(jsonBytes)
+		return err
+	}
+
+	// The leading comment keeps the callback from being the first bytes of the response
+	buf := make([]byte, 0, len("/**/")+len(r.Callback)+len(jsonBytes)+3)
+	buf = append(buf, "/**/"...)
+	buf = append(buf, r.Callback...)
+	buf = append(buf, '(')
+	buf = append(buf, jsonBytes...)
+	buf = append(buf, ");"...)
+	_, err = w.Write(buf)
+	return err
+}
+
+// WriteContentType (JsonpJSON) writes Javascript ContentType.
+func (r JsonpJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonpContentType)
+}
+
+// Render (AsciiJSON) marshals the given interface object and writes it with custom ContentType.
+// The non ASCII characters are escaped as \uXXXX, as a surrogate pair outside of the BMP.
+func (r AsciiJSON) Render(w http.ResponseWriter) error {
+	jsonBytes, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+
+	var buffer bytes.Buffer
+	buffer.Grow(len(jsonBytes))
+	for _, c := range string(jsonBytes) {
+		switch {
+		case c < 128:
+			buffer.WriteByte(byte(c))
+		case c > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(c)
+			writeUnicodeEscape(&buffer, r1)
+			writeUnicodeEscape(&buffer, r2)
+		default:
+			writeUnicodeEscape(&buffer, c)
+		}
+	}
+
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
+
+func writeUnicodeEscape(buf *bytes.Buffer, c rune) {
+	buf.WriteString(`\u`)
+	hex := strconv.FormatInt(int64(c), 16)
+	for i := len(hex); i < 4; i++ {
+		buf.WriteByte('0')
+	}
+	buf.WriteString(hex)
+}
+
+// WriteContentType (AsciiJSON) writes JSON ContentType.
+func (r AsciiJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonASCIIContentType)
+}
+
+// Render (PureJSON) writes custom ContentType and encodes the given interface object
+// without escaping the HTML characters, e.g. '<' is written as is instead of \u003c.
+func (r PureJSON) Render(w http.ResponseWriter) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.Data); err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// WriteContentType (PureJSON) writes custom ContentType.
+func (r PureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
@@ -0,0 +1,27 @@
+package render
+
+import "net/http"
+
+// MsgPack contains the given interface object.
+type MsgPack struct {
+	Data any
+}
+
+var msgpackContentType = []string{"application/msgpack"}
+
+// Render (MsgPack) encodes the given interface object and writes data with custom ContentType.
+// It returns ErrCodecNotBuilt if the program was built without the msgpack build tag.
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	out, err := marshal(marshalMsgPack, "MsgPack", "msgpack", r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(out)
+	return err
+}
+
+// WriteContentType (MsgPack) writes MsgPack ContentType for response.
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
@@ -0,0 +1,9 @@
+//go:build msgpack
+
+package render
+
+import "github.com/vmihailenco/msgpack/v5"
+
+func init() {
+	marshalMsgPack = msgpack.Marshal
+}
@@ -0,0 +1,28 @@
+package render
+
+import "net/http"
+
+// ProtoBuf contains the given interface object.
+// Data must be a proto.Message.
+type ProtoBuf struct {
+	Data any
+}
+
+var protobufContentType = []string{"application/x-protobuf"}
+
+// Render (ProtoBuf) encodes the given interface object and writes data with custom ContentType.
+// It returns ErrCodecNotBuilt if the program was built without the protobuf build tag.
+func (r ProtoBuf) Render(w http.ResponseWriter) error {
+	out, err := marshal(marshalProtoBuf, "ProtoBuf", "protobuf", r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(out)
+	return err
+}
+
+// WriteContentType (ProtoBuf) writes ProtoBuf ContentType for response.
+func (r ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, protobufContentType)
+}
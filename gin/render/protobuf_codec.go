@@ -0,0 +1,19 @@
+//go:build protobuf
+
+package render
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	marshalProtoBuf = func(v any) ([]byte, error) {
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("render: ProtoBuf needs a proto.Message, got %T", v)
+		}
+		return proto.Marshal(msg)
+	}
+}
@@ -0,0 +1,39 @@
+// Package render writes the response bodies of the gin handlers, one Render per format.
+package render
+
+import "net/http"
+
+// Render interface is to be implemented by JSON, XML, HTML, YAML and so on.
+type Render interface {
+	// Render writes data with custom ContentType.
+	Render(http.ResponseWriter) error
+	// WriteContentType writes custom ContentType.
+	WriteContentType(w http.ResponseWriter)
+}
+
+var (
+	_ Render = JSON{}
+	_ Render = IndentedJSON{}
+	_ Render = SecureJSON{}
+	_ Render = JsonpJSON{}
+	_ Render = AsciiJSON{}
+	_ Render = PureJSON{}
+	_ Render = XML{}
+	_ Render = YAML{}
+	_ Render = TOML{}
+	_ Render = MsgPack{}
+	_ Render = ProtoBuf{}
+	_ Render = HTML{}
+
+	_ HTMLRender = HTMLDebug{}
+	_ HTMLRender = HTMLProduction{}
+	_ HTMLRender = (*HTMLTemplates)(nil)
+)
+
+// writeContentType sets the Content-Type header unless the handler already set one.
+func writeContentType(w http.ResponseWriter, value []string) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = value
+	}
+}
@@ -0,0 +1,27 @@
+package render
+
+import "net/http"
+
+// TOML contains the given interface object.
+type TOML struct {
+	Data any
+}
+
+var tomlContentType = []string{"application/toml; charset=utf-8"}
+
+// Render (TOML) encodes the given interface object and writes data with custom ContentType.
+// It returns ErrCodecNotBuilt if the program was built without the toml build tag.
+func (r TOML) Render(w http.ResponseWriter) error {
+	out, err := marshal(marshalTOML, "TOML", "toml", r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(out)
+	return err
+}
+
+// WriteContentType (TOML) writes TOML ContentType for response.
+func (r TOML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, tomlContentType)
+}
@@ -0,0 +1,9 @@
+//go:build toml
+
+package render
+
+import "github.com/pelletier/go-toml/v2"
+
+func init() {
+	marshalTOML = toml.Marshal
+}
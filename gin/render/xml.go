@@ -0,0 +1,29 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// XML contains the given interface object.
+type XML struct {
+	Data any
+}
+
+var xmlContentType = []string{"application/xml; charset=utf-8"}
+
+// Render (XML) encodes the given interface object and writes data with custom ContentType.
+func (r XML) Render(w http.ResponseWriter) error {
+	xmlBytes, err := xml.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(xmlBytes)
+	return err
+}
+
+// WriteContentType (XML) writes XML ContentType for response.
+func (r XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, xmlContentType)
+}
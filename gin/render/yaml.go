@@ -0,0 +1,27 @@
+package render
+
+import "net/http"
+
+// YAML contains the given interface object.
+type YAML struct {
+	Data any
+}
+
+var yamlContentType = []string{"application/yaml; charset=utf-8"}
+
+// Render (YAML) encodes the given interface object and writes data with custom ContentType.
+// It returns ErrCodecNotBuilt if the program was built without the yaml build tag.
+func (r YAML) Render(w http.ResponseWriter) error {
+	out, err := marshal(marshalYAML, "YAML", "yaml", r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(out)
+	return err
+}
+
+// WriteContentType (YAML) writes YAML ContentType for response.
+func (r YAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, yamlContentType)
+}
@@ -0,0 +1,9 @@
+//go:build yaml
+
+package render
+
+import "gopkg.in/yaml.v3"
+
+func init() {
+	marshalYAML = yaml.Marshal
+}
@@ -2,6 +2,7 @@ package gin
 
 import (
 	"bufio"
+	"io"
 	"net"
 	"net/http"
 )
@@ -17,6 +18,8 @@ type responseWriter struct {
 	status int
 }
 
+var _ ResponseWriter = (*responseWriter)(nil)
+
 func (w *responseWriter) Unwrap() http.ResponseWriter {
 	return w.ResponseWriter
 }
@@ -29,67 +32,82 @@ func (w *responseWriter) reset(writer http.ResponseWriter) {
 	w.status = defaultStatus
 }
 
-// CloseNotify implements ResponseWriter.
-func (r *responseWriter) CloseNotify() <-chan bool {
-	panic("unimplemented")
-}
-
-// Flush implements ResponseWriter.
-func (r *responseWriter) Flush() {
-	panic("unimplemented")
+// WriteHeader records the status code, the header is sent lazily by WriteHeaderNow.
+// 只记录状态码，真正写出 header 是在 WriteHeaderNow 或者第一次 Write 的时候
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && w.status != code {
+		if w.Written() {
+			debugPrint("[WARNING] Headers were already written. Wanted to override status code %d with %d", w.status, code)
+			return
+		}
+		w.status = code
+	}
 }
 
-// Header implements ResponseWriter.
-// Subtle: this method shadows the method (ResponseWriter).Header of responseWriter.ResponseWriter.
-func (r *responseWriter) Header() http.Header {
-	panic("unimplemented")
+// WriteHeaderNow forces to write the http header (status code + headers).
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
 }
 
-// Hijack implements ResponseWriter.
-func (r *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	panic("unimplemented")
+// Write writes data into the response body, sending the header first if needed.
+func (w *responseWriter) Write(data []byte) (n int, err error) {
+	w.WriteHeaderNow()
+	n, err = w.ResponseWriter.Write(data)
+	w.size += n
+	return
 }
 
-// Pusher implements ResponseWriter.
-func (r *responseWriter) Pusher() http.Pusher {
-	panic("unimplemented")
+// WriteString writes the string into the response body.
+func (w *responseWriter) WriteString(s string) (n int, err error) {
+	w.WriteHeaderNow()
+	n, err = io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return
 }
 
-// Size implements ResponseWriter.
-func (r *responseWriter) Size() int {
-	panic("unimplemented")
+// Status returns the HTTP response status code of the current request.
+func (w *responseWriter) Status() int {
+	return w.status
 }
 
-// Status implements ResponseWriter.
-func (r *responseWriter) Status() int {
-	panic("unimplemented")
+// Size returns the number of bytes already written into the response http body.
+func (w *responseWriter) Size() int {
+	return w.size
 }
 
-// Write implements ResponseWriter.
-// Subtle: this method shadows the method (ResponseWriter).Write of responseWriter.ResponseWriter.
-func (r *responseWriter) Write([]byte) (int, error) {
-	panic("unimplemented")
+// Written returns true if the response header was already written.
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
 }
 
-// WriteHeader implements ResponseWriter.
-// Subtle: this method shadows the method (ResponseWriter).WriteHeader of responseWriter.ResponseWriter.
-func (r *responseWriter) WriteHeader(statusCode int) {
-	panic("unimplemented")
+// Hijack implements the http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.size < 0 {
+		w.size = 0
+	}
+	return w.ResponseWriter.(http.Hijacker).Hijack()
 }
 
-// WriteHeaderNow implements ResponseWriter.
-func (r *responseWriter) WriteHeaderNow() {
-	panic("unimplemented")
+// CloseNotify implements the http.CloseNotifier interface.
+func (w *responseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
 }
 
-// WriteString implements ResponseWriter.
-func (r *responseWriter) WriteString(string) (int, error) {
-	panic("unimplemented")
+// Flush implements the http.Flusher interface.
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	w.ResponseWriter.(http.Flusher).Flush()
 }
 
-// Written implements ResponseWriter.
-func (r *responseWriter) Written() bool {
-	panic("unimplemented")
+// Pusher get the http.Pusher for server push
+func (w *responseWriter) Pusher() (pusher http.Pusher) {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher
+	}
+	return nil
 }
 
 // ResponseWriter ...
@@ -0,0 +1,179 @@
+package gin
+
+import "maps"
+
+// routeTable holds everything needed to route a request. Once the engine serves requests
+// a routeTable is never changed again, every change is made on a copy which then atomically
+// replaces it, so a request is routed with the same table from its start to its end.
+// 路由表快照，开始处理请求之后不再原地修改，而是复制一份修改后整体替换（copy-on-write）
+type routeTable struct {
+	// trees holds the route tree of each method.
+	// trees 保存每个 method 对应的路由树，每棵树的 root 节点是一个 node 类型，表示路由树的根节点。
+	trees methodTrees
+
+	// hosts are the route trees registered through Engine.Host, exact patterns first.
+	hosts []*hostTrees
+
+	// routeNames maps a route name to the route it was registered with.
+	routeNames map[string]namedRoute
+
+	maxParams uint16
+
+	maxSections uint16
+
+	// sealed is set once the table built by Engine.Reload has been published.
+	sealed bool
+}
+
+// addRoute registers handlers for method and path, creating the method tree on demand.
+// The route goes to the trees of the host pattern if host is not empty.
+// 把路由注册到对应 method 的路由树中，如果该 method 的树不存在则新建
+func (t *routeTable) addRoute(host, method, path string, handlers HandlersChain) {
+	assert1(path[0] == '/', "path must begin with '/'")
+	assert1(method != "", "HTTP method can not be empty")
+	assert1(len(handlers) > 0, "there must be at least one handler")
+
+	debugPrintRoute(method, host+path, handlers)
+
+	trees := &t.trees
+	var extraParams uint16
+	if host != "" {
+		h := t.hostTrees(host)
+		trees = &h.trees
+		extraParams = h.paramsCount
+	}
+
+	root := trees.get(method)
+	if root == nil {
+		root = new(node)
+		root.fullPath = "/"
+		trees.add(method, root)
+	}
+	root.addRoute(path, handlers)
+
+	// 更新 maxParams 和 maxSections，Context 对象池按这两个值预分配空间
+	if paramsCount := countParams(path) + extraParams; paramsCount > t.maxParams {
+		t.maxParams = paramsCount
+	}
+
+	if sectionsCount := countSections(path); sectionsCount > t.maxSections {
+		t.maxSections = sectionsCount
+	}
+}
+
+// removeRoute removes the route registered for method and path from the default trees.
+// It returns false if there is no such route.
+func (t *routeTable) removeRoute(method, path string) bool {
+	root := t.trees.get(method)
+	if root == nil {
+		return false
+	}
+	chain := root.findRoute(path, nil)
+	if chain == nil {
+		return false
+	}
+
+	// Drop the handlers of the leaf, then prune the nodes left without handlers and children
+	leaf := chain[len(chain)-1]
+	leaf.handlers = nil
+	leaf.meta = nil
+	// the priority of a node is the number of handlers below it
+	for _, n := range chain {
+		n.priority--
+	}
+	for i := len(chain) - 1; i > 0; i-- {
+		n, parent := chain[i], chain[i-1]
+		if n.handlers != nil || len(n.children) > 0 {
+			break
+		}
+		parent.removeChild(n)
+	}
+
+	// Forget the names of the path unless another method still serves it
+	for _, tree := range t.trees.list {
+		if tree.root.findRoute(path, nil) != nil {
+			return true
+		}
+	}
+	for name, route := range t.routeNames {
+		if route.path == path {
+			delete(t.routeNames, name)
+		}
+	}
+	return true
+}
+
+// namedRoute is a route registered with RouterGroup.Name.
+type namedRoute struct {
+	// path is the full path pattern of the route.
+	path string
+	// constraints holds the constraint of each :param of path in order, nil for an
+	// unconstrained one, so that Engine.URL does not compile them again on each call.
+	constraints []*paramConstraint
+}
+
+// addRouteName records the path pattern of a named route.
+func (t *routeTable) addRouteName(name, path string) {
+	assert1(name != "", "route name can not be empty")
+	if old, ok := t.routeNames[name]; ok {
+		if old.path != path {
+			panic("route name '" + name + "' is already registered for path '" + old.path + "'")
+		}
+		return
+	}
+	if t.routeNames == nil {
+		t.routeNames = make(map[string]namedRoute)
+	}
+	t.routeNames[name] = namedRoute{path: path, constraints: pathConstraints(path)}
+}
+
+// setRouteMeta attaches key and value to the route registered for path and each of methods.
+func (t *routeTable) setRouteMeta(host string, methods []string, path, key string, value any) {
+	trees := &t.trees
+	if host != "" {
+		trees = &t.hostTrees(host).trees
+	}
+	for _, method := range methods {
+		root := trees.get(method)
+		assert1(root != nil, "there is no "+method+" route for path '"+path+"'")
+		chain := root.findRoute(path, nil)
+		assert1(chain != nil, "there is no "+method+" route for path '"+path+"'")
+
+		leaf := chain[len(chain)-1]
+		meta := maps.Clone(leaf.meta)
+		if meta == nil {
+			meta = make(map[string]any, 1)
+		}
+		meta[key] = value
+		leaf.meta = meta
+	}
+}
+
+// clone returns a deep copy of the table which can be changed without affecting t.
+func (t *routeTable) clone() *routeTable {
+	cp := &routeTable{
+		trees:       t.trees.clone(),
+		hosts:       make([]*hostTrees, len(t.hosts)),
+		routeNames:  maps.Clone(t.routeNames),
+		maxParams:   t.maxParams,
+		maxSections: t.maxSections,
+	}
+	for i, h := range t.hosts {
+		hc := *h
+		hc.trees = h.trees.clone()
+		cp.hosts[i] = &hc
+	}
+	return cp
+}
+
+// updateRouteTrees do update to the route trees
+func (t *routeTable) updateRouteTrees() {
+	for _, tree := range t.trees.list {
+		updateRouteTree(tree.root)
+	}
+	for _, h := range t.hosts {
+		for _, tree := range h.trees.list {
+			updateRouteTree(tree.root)
+		}
+	}
+}
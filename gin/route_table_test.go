@@ -0,0 +1,195 @@
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestRouteTableRemoveRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		remove []string
+		add    []string
+		// requests are checked once the routes of add are registered
+		requests testRequests
+	}{
+		{
+			name:   "catch-all",
+			routes: []string{"/src/*filepath", "/srv"},
+			remove: []string{"/src/*filepath"},
+			add:    []string{"/src/:file", "/src/static.json"},
+			requests: testRequests{
+				{"/src/a/b", true, "", Params{{Key: "file", Value: "a"}}},
+				{"/src/app.js", false, "/src/:file", Params{{Key: "file", Value: "app.js"}}},
+				{"/src/static.json", false, "/src/static.json", nil},
+				{"/srv", false, "/srv", nil},
+			},
+		},
+		{
+			name:   "param with a child",
+			routes: []string{"/users/:id", "/users/:id/posts"},
+			remove: []string{"/users/:id/posts", "/users/:id"},
+			add:    []string{"/users/:name/comments", "/users/new"},
+			requests: testRequests{
+				{"/users/42", true, "", Params{{Key: "name", Value: "42"}}},
+				{"/users/42/posts", true, "", Params{{Key: "name", Value: "42"}}},
+				{"/users/bob/comments", false, "/users/:name/comments", Params{{Key: "name", Value: "bob"}}},
+				{"/users/new", false, "/users/new", nil},
+			},
+		},
+		{
+			name:   "param keeping its child",
+			routes: []string{"/users/:id", "/users/:id/posts"},
+			remove: []string{"/users/:id"},
+			add:    []string{"/users/:id/comments", "/users/:id"},
+			requests: testRequests{
+				{"/users/42/posts", false, "/users/:id/posts", Params{{Key: "id", Value: "42"}}},
+				{"/users/42", false, "/users/:id", Params{{Key: "id", Value: "42"}}},
+				{"/users/42/comments", false, "/users/:id/comments", Params{{Key: "id", Value: "42"}}},
+			},
+		},
+		{
+			name:   "static siblings",
+			routes: []string{"/a/b", "/a/bc", "/a/c", "/a/:x"},
+			remove: []string{"/a/b", "/a/:x"},
+			add:    []string{"/a/bb", "/a/:y"},
+			requests: testRequests{
+				{"/a/b", false, "/a/:y", Params{{Key: "y", Value: "b"}}},
+				{"/a/bc", false, "/a/bc", nil},
+				{"/a/bb", false, "/a/bb", nil},
+				{"/a/c", false, "/a/c", nil},
+				{"/a/d", false, "/a/:y", Params{{Key: "y", Value: "d"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &routeTable{}
+			for _, route := range tt.routes {
+				table.addRoute("", http.MethodGet, route, fakeHandler(route))
+			}
+			for _, route := range tt.remove {
+				if !table.removeRoute(http.MethodGet, route) {
+					t.Fatalf("route '%s' was not removed", route)
+				}
+			}
+			if table.removeRoute(http.MethodGet, tt.remove[0]) {
+				t.Errorf("route '%s' was removed twice", tt.remove[0])
+			}
+
+			root := table.trees.get(http.MethodGet)
+			for _, route := range tt.add {
+				if recv := catchPanic(func() {
+					table.addRoute("", http.MethodGet, route, fakeHandler(route))
+				}); recv != nil {
+					t.Fatalf("unexpected panic for route '%s' after removal: %v", route, recv)
+				}
+			}
+			checkRequests(t, root, tt.requests)
+			checkPriorities(t, root)
+		})
+	}
+}
+
+// TestRouteTableConcurrentChanges serves requests while the routes are changed,
+// it is meant to be run with -race.
+func TestRouteTableConcurrentChanges(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(c *Context) {})
+	router.GET("/static/*filepath", func(c *Context) {})
+
+	const changes = 50
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, path := range []string{"/users/42", "/static/app.js", "/reload/7/a/b", "/missing"} {
+					w := performRequest(router, http.MethodGet, path)
+					if w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+						t.Errorf("GET %s: unexpected status %d", path, w.Code)
+					}
+				}
+			}
+		}()
+	}
+
+	// serve at least once before changing the routes, so that they are copied on write
+	performRequest(router, http.MethodGet, "/users/42")
+	for i := range changes {
+		path := fmt.Sprintf("/reload/%d/:a/:b", i)
+		router.Reload(func(r *RouterGroup) {
+			r.GET(path, func(c *Context) {})
+		})
+		if i > 0 && !router.RemoveRoute(http.MethodGet, fmt.Sprintf("/reload/%d/:a/:b", i-1)) {
+			t.Errorf("route %d was not removed", i-1)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if w := performRequest(router, http.MethodGet, fmt.Sprintf("/reload/%d/a/b", changes-1)); w.Code != http.StatusOK {
+		t.Errorf("last reloaded route: got status %d", w.Code)
+	}
+	if w := performRequest(router, http.MethodGet, "/reload/0/a/b"); w.Code != http.StatusNotFound {
+		t.Errorf("removed route: got status %d", w.Code)
+	}
+}
+
+func TestReloadRejectsSPA(t *testing.T) {
+	router := New()
+	recv := catchPanic(func() {
+		router.Reload(func(r *RouterGroup) {
+			r.SPA("/app", http.Dir("."), "index.html")
+		})
+	})
+	if recv != "SPA can not be used on the RouterGroup of Engine.Reload" {
+		t.Errorf("SPA in Reload: got panic %v", recv)
+	}
+	if len(router.spa) != 0 {
+		t.Errorf("SPA in Reload: %d fallbacks registered", len(router.spa))
+	}
+}
+
+func TestReloadRejectsEngineChanges(t *testing.T) {
+	const msg = "the routes of the engine can not be changed inside Reload, use the RouterGroup given to fn"
+	tests := []struct {
+		name  string
+		fn    func(router *Engine)
+		panic string
+	}{
+		{"engine route", func(router *Engine) { router.GET("/a", func(c *Context) {}) }, msg},
+		{"host route", func(router *Engine) { router.Host("api.example.com").GET("/a", func(c *Context) {}) }, msg},
+		{"remove route", func(router *Engine) { router.RemoveRoute(http.MethodGet, "/ok") }, msg},
+		{"nested reload", func(router *Engine) { router.Reload(func(r *RouterGroup) {}) }, "Reload can not be called inside Reload"},
+	}
+	for _, tt := range tests {
+		router := New()
+		router.GET("/ok", func(c *Context) {})
+		recv := catchPanic(func() {
+			router.Reload(func(r *RouterGroup) {
+				r.GET("/reloaded", func(c *Context) {})
+				tt.fn(router)
+			})
+		})
+		if recv != tt.panic {
+			t.Errorf("%s: got panic %v, want %q", tt.name, recv, tt.panic)
+		}
+		// nothing is changed and the engine is still usable
+		if _, _, _, found := router.Lookup(http.MethodGet, "/reloaded"); found {
+			t.Errorf("%s: the routes of the failed Reload were published", tt.name)
+		}
+		router.GET("/after", func(c *Context) {})
+	}
+}
@@ -1,6 +1,26 @@
 package gin
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// regEnLetter matches english letters for http method name
+	regEnLetter = regexp.MustCompile("^[A-Z]+$")
+
+	// anyMethods for RouterGroup Any method
+	anyMethods = []string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodConnect,
+		http.MethodTrace,
+	}
+)
 
 // HandlerFunc defines the handler used by gin middleware as return value.
 // HandlerFunc 定义了 gin 中间件使用的处理函数类型。
@@ -10,82 +30,387 @@ type HandlerFunc func(*Context)
 // 一个 HandlerFunc 切片类型，表示一组处理函数链。
 type HandlersChain []HandlerFunc
 
+// Last returns the last handler in the chain. i.e. the last handler is the main one.
+func (c HandlersChain) Last() HandlerFunc {
+	if length := len(c); length > 0 {
+		return c[length-1]
+	}
+	return nil
+}
+
+// RouteInfo represents a request route's specification which contains method and path and its handler.
+type RouteInfo struct {
+	// Host is the host pattern of routes registered through Engine.Host, empty otherwise.
+	Host        string
+	Method      string
+	Path        string
+	Handler     string
+	HandlerFunc HandlerFunc
+	// Meta is the metadata attached with RouterGroup.Meta, it must not be modified.
+	Meta map[string]any
+}
+
+// RoutesInfo defines a RouteInfo slice.
+type RoutesInfo []RouteInfo
+
+// IRouter defines all router handle interface includes single and group router.
+type IRouter interface {
+	IRoutes
+	Group(string, ...HandlerFunc) *RouterGroup
+}
+
+// RouterGroup is used internally to configure router, a RouterGroup is associated with
+// a prefix and an array of handlers (middleware).
+// 路由分组，basePath 是分组的公共前缀，Handlers 是分组共享的中间件
 type RouterGroup struct {
 	Handlers HandlersChain
 	basePath string
 	engine   *Engine
 	root     bool
+
+	// host is the pattern of the groups created by Engine.Host, their routes go to the host's own trees.
+	host string
+
+	// table is set for the groups of Engine.Reload, their routes go to the table being built.
+	table *routeTable
+
+	// lastPaths and lastMethods identify the most recently registered routes, used by Name and Meta.
+	// Mount registers two paths, the prefix and its catch-all.
+	// 最近一次注册的路由的绝对路径和方法，Name 和 Meta 方法通过它们找到路由
+	lastPaths   []string
+	lastMethods []string
 }
 
-// Any implements IRoutes.
-func (group *RouterGroup) Any(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+var _ IRouter = (*RouterGroup)(nil)
+
+// Group creates a new router group. You should add all the routes that have common middlewares or the same path prefix.
+// For example, all the routes that use a common middleware for authorization could be grouped.
+// 子分组会复制父分组的 Handlers，兄弟分组之间不会共享同一个底层数组
+func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		Handlers: group.combineHandlers(handlers),
+		basePath: group.calculateAbsolutePath(relativePath),
+		engine:   group.engine,
+		host:     group.host,
+		table:    group.table,
+	}
 }
 
-// DELETE implements IRoutes.
-func (group *RouterGroup) DELETE(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// BasePath returns the base path of router group.
+// For example, if v := router.Group("/rest/n/v1/api"), v.BasePath() is "/rest/n/v1/api".
+func (group *RouterGroup) BasePath() string {
+	return group.basePath
 }
 
-// GET implements IRoutes.
-func (group *RouterGroup) GET(string, ...HandlerFunc) IRoutes {
-	//TODO modify the path
+// Any registers a route that matches all the HTTP methods.
+// GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
+func (group *RouterGroup) Any(relativePath string, handlers ...HandlerFunc) IRoutes {
+	for _, method := range anyMethods {
+		group.handle(method, relativePath, handlers)
+	}
+	group.lastMethods = anyMethods
+
+	return group.returnObj()
+}
+
+// DELETE is a shortcut for router.Handle("DELETE", path, handlers).
+func (group *RouterGroup) DELETE(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodDelete, relativePath, handlers)
+}
+
+// GET is a shortcut for router.Handle("GET", path, handlers).
+func (group *RouterGroup) GET(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodGet, relativePath, handlers)
+}
+
+// HEAD is a shortcut for router.Handle("HEAD", path, handlers).
+func (group *RouterGroup) HEAD(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodHead, relativePath, handlers)
+}
+
+// Handle registers a new request handle and middleware with the given path and method.
+// The last handler should be the real handler, the other ones should be middleware that can and should be shared among different routes.
+// See the example code in GitHub.
+//
+// For GET, POST, PUT, PATCH and DELETE requests the respective shortcut
+// functions can be used.
+//
+// This function is intended for bulk loading and to allow the usage of less
+// frequently used, non-standardized or custom methods (e.g. for internal
+// communication with a proxy).
+func (group *RouterGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) IRoutes {
+	assertMethod(httpMethod)
+	return group.handle(httpMethod, relativePath, handlers)
+}
+
+// Match registers a route that matches the specified methods that you declared.
+// Custom methods such as PROPFIND or PURGE are accepted as well.
+func (group *RouterGroup) Match(methods []string, relativePath string, handlers ...HandlerFunc) IRoutes {
+	for _, method := range methods {
+		assertMethod(method)
+		group.handle(method, relativePath, handlers)
+	}
+	group.lastMethods = slices.Clone(methods)
+
+	return group.returnObj()
+}
+
+// Mount registers h to serve every request whose path is prefix or starts with prefix + "/",
+// for all the methods. The middleware of the group still runs before h, and the absolute
+// prefix is stripped from Request.URL.Path and RawPath of the request h receives,
+// so h sees "/" for the prefix itself.
+//
+//	router.Mount("/files", http.FileServer(http.Dir("./public")))
+//
+// 把任意 http.Handler 挂载到 prefix 下，调用前去掉路径中的前缀
+func (group *RouterGroup) Mount(prefix string, h http.Handler) IRoutes {
+	assert1(!strings.ContainsAny(prefix, ":*"), "wildcards are not allowed in mount prefix '"+prefix+"'")
+	relativePath := strings.TrimSuffix(prefix, "/")
+	absolutePrefix := strings.TrimSuffix(group.calculateAbsolutePath(relativePath), "/")
+
+	handler := func(c *Context) {
+		r := c.Request
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = stripMountPrefix(r.URL.Path, absolutePrefix)
+		if r.URL.RawPath != "" {
+			if strings.HasPrefix(r.URL.RawPath, absolutePrefix) {
+				r2.URL.RawPath = stripMountPrefix(r.URL.RawPath, absolutePrefix)
+			} else {
+				// the prefix is escaped differently, let URL escape the stripped path
+				r2.URL.RawPath = ""
+			}
+		}
+		h.ServeHTTP(c.Writer, r2)
+	}
+
+	handlers := HandlersChain{handler}
+	paths := []string{relativePath + "/*path"}
+	if absolutePrefix != "" {
+		paths = []string{relativePath, relativePath + "/*path"}
+	}
+	for _, method := range anyMethods {
+		for _, p := range paths {
+			group.handle(method, p, handlers)
+		}
+	}
+	// Name and Meta apply to both routes
+	group.lastPaths = make([]string, len(paths))
+	for i, p := range paths {
+		group.lastPaths[i] = group.calculateAbsolutePath(p)
+	}
+	group.lastMethods = anyMethods
+
 	return group.returnObj()
 }
 
-// HEAD implements IRoutes.
-func (group *RouterGroup) HEAD(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// stripMountPrefix removes prefix from path, an empty result becomes "/".
+func stripMountPrefix(path, prefix string) string {
+	if path = strings.TrimPrefix(path, prefix); path == "" {
+		return "/"
+	}
+	return path
+}
+
+// OPTIONS is a shortcut for router.Handle("OPTIONS", path, handlers).
+func (group *RouterGroup) OPTIONS(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodOptions, relativePath, handlers)
 }
 
-// Handle implements IRoutes.
-func (group *RouterGroup) Handle(string, string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// PATCH is a shortcut for router.Handle("PATCH", path, handlers).
+func (group *RouterGroup) PATCH(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodPatch, relativePath, handlers)
 }
 
-// Match implements IRoutes.
-func (group *RouterGroup) Match([]string, string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// POST is a shortcut for router.Handle("POST", path, handlers).
+func (group *RouterGroup) POST(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodPost, relativePath, handlers)
 }
 
-// OPTIONS implements IRoutes.
-func (group *RouterGroup) OPTIONS(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// PUT is a shortcut for router.Handle("PUT", path, handlers).
+func (group *RouterGroup) PUT(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return group.handle(http.MethodPut, relativePath, handlers)
 }
 
-// PATCH implements IRoutes.
-func (group *RouterGroup) PATCH(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// StaticFile registers a single route in order to serve a single file of the local filesystem.
+// router.StaticFile("favicon.ico", "./resources/favicon.ico")
+func (group *RouterGroup) StaticFile(relativePath, file string) IRoutes {
+	dir, name := filepath.Split(file)
+	return group.staticFileHandler(relativePath, http.Dir(dir), name)
 }
 
-// POST implements IRoutes.
-func (group *RouterGroup) POST(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+// StaticFileFS works just like `StaticFile` but a custom `http.FileSystem` can be used instead,
+// e.g. an embed.FS through http.FS.
+// router.StaticFileFS("favicon.ico", "./resources/favicon.ico", gin.Dir(".", false))
+func (group *RouterGroup) StaticFileFS(relativePath, file string, fs http.FileSystem) IRoutes {
+	return group.staticFileHandler(relativePath, fs, file)
 }
 
-// PUT implements IRoutes.
-func (group *RouterGroup) PUT(string, ...HandlerFunc) IRoutes {
-	panic("unimplemented")
+func (group *RouterGroup) staticFileHandler(relativePath string, fs http.FileSystem, name string) IRoutes {
+	assertStaticPath(relativePath, "file")
+	server := newFileServer(fs)
+	handler := func(c *Context) {
+		if !server.serve(c, name) {
+			group.engine.serveNotFound(c)
+		}
+	}
+	group.GET(relativePath, handler)
+	group.HEAD(relativePath, handler)
+	group.lastMethods = []string{http.MethodGet, http.MethodHead}
+	return group.returnObj()
 }
 
-// Static implements IRoutes.
-func (group *RouterGroup) Static(string, string) IRoutes {
-	panic("unimplemented")
+// Static serves files from the given file system root.
+// The files which do not exist are answered with the NoRoute handlers of the engine.
+// The directories are not listed, a directory is only served through its index.html.
+// To use the operating system's file system implementation,
+// use :
+//
+//	router.Static("/static", "/var/www")
+func (group *RouterGroup) Static(relativePath, root string) IRoutes {
+	return group.StaticFS(relativePath, Dir(root, false))
 }
 
-// StaticFS implements IRoutes.
-func (group *RouterGroup) StaticFS(string, http.FileSystem) IRoutes {
-	panic("unimplemented")
+// StaticFS works just like `Static()` but a custom `http.FileSystem` can be used instead.
+// An fs.FS, such as an embed.FS, is served through http.FS:
+//
+//	//go:embed assets
+//	var assets embed.FS
+//	sub, _ := fs.Sub(assets, "assets")
+//	router.StaticFS("/assets", &gin.OnlyFilesFS{FileSystem: http.FS(sub)})
+//
+// The HEAD requests, If-Modified-Since and ETag validation are answered, and a path
+// with a ".." element is rejected with 400.
+// When the request accepts it, the precompressed sibling of a file (app.js.br, app.js.zst
+// or app.js.gz for app.js) is served instead, with the content type of the file.
+func (group *RouterGroup) StaticFS(relativePath string, fs http.FileSystem) IRoutes {
+	assertStaticPath(relativePath, "folder")
+	server := newFileServer(fs)
+	handler := func(c *Context) {
+		if !server.serve(c, c.Param("filepath")) {
+			group.engine.serveNotFound(c)
+		}
+	}
+	urlPattern := path.Join(relativePath, "/*filepath")
+
+	// Register GET and HEAD handlers
+	group.GET(urlPattern, handler)
+	group.HEAD(urlPattern, handler)
+	group.lastMethods = []string{http.MethodGet, http.MethodHead}
+	return group.returnObj()
 }
 
-// StaticFile implements IRoutes.
-func (group *RouterGroup) StaticFile(string, string) IRoutes {
-	panic("unimplemented")
+// SPA serves the single page application of fs under prefix: the files of fs are served
+// as with StaticFS, and any other GET or HEAD request under prefix gets indexFile, so that
+// the client side router handles the path.
+// The fallback runs in the NoRoute chain, before the NoRoute handlers, so the routes
+// under prefix keep precedence, and only the middleware of the engine runs.
+// indexFile is only served to the requests which explicitly accept text/html, as the
+// browser navigations do; the API clients, which send Accept: application/json or */*,
+// keep getting 404 for unknown paths.
+// The fallbacks belong to the engine rather than to the route table, so SPA must be
+// called before serving and panics on the RouterGroup of Engine.Reload.
+//
+//	router.SPA("/app", http.FS(dist), "index.html")
+//
+// 单页应用：真实存在的文件直接返回，其他接受 text/html 的 GET 请求返回 indexFile
+func (group *RouterGroup) SPA(prefix string, fs http.FileSystem, indexFile string) IRoutes {
+	assert1(group.host == "", "SPA can not be used on the RouterGroup of Engine.Host")
+	assert1(group.table == nil, "SPA can not be used on the RouterGroup of Engine.Reload")
+	assertStaticPath(prefix, "folder")
+	absolutePrefix := strings.TrimSuffix(group.calculateAbsolutePath(prefix), "/")
+	// the directories of a single page application are never listed
+	server := newFileServer(&OnlyFilesFS{FileSystem: fs})
+
+	engine := group.engine
+	engine.spa = append(engine.spa, func(c *Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		file, ok := strings.CutPrefix(c.Request.URL.Path, absolutePrefix)
+		if !ok || file != "" && file[0] != '/' {
+			return
+		}
+
+		// The prefix itself is not redirected to prefix + "/", the router may redirect
+		// the latter to the former, it gets indexFile as the unknown paths
+		if file != "" && server.serve(c, file) {
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept")
+		if acceptsHTML(c.Request.Header.Get("Accept")) && server.serve(c, indexFile) {
+			c.Abort()
+		}
+	})
+	engine.rebuild404Handlers()
+	return group.returnObj()
 }
 
-// StaticFileFS implements IRoutes.
-func (group *RouterGroup) StaticFileFS(string, string, http.FileSystem) IRoutes {
-	panic("unimplemented")
+// assertStaticPath panics if relativePath, the path of a static what, has wildcards.
+func assertStaticPath(relativePath, what string) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("URL parameters can not be used when serving a static " + what +
+			", has: '" + relativePath + "'; register a route with a handler calling http.ServeContent instead")
+	}
+}
+
+// handle merges the group middleware with handlers and registers the route in the engine trees.
+func (group *RouterGroup) handle(httpMethod, relativePath string, handlers HandlersChain) IRoutes {
+	absolutePath := group.calculateAbsolutePath(relativePath)
+	handlers = group.combineHandlers(handlers)
+	group.updateRoutes(func(t *routeTable) {
+		t.addRoute(group.host, httpMethod, absolutePath, handlers)
+	})
+	group.lastPaths = []string{absolutePath}
+	group.lastMethods = []string{httpMethod}
+	return group.returnObj()
+}
+
+// Name gives the most recently registered route of the group a name, so that
+// its URL can be built with Engine.URL or Context.URLFor.
+// After Mount the name is given to the prefix, which serves the catch-all as well.
+//
+//	router.GET("/users/:id/posts/*rest", handler).Name("user.posts")
+func (group *RouterGroup) Name(name string) IRoutes {
+	assert1(len(group.lastPaths) > 0, "there is no route to name, register a route before calling Name")
+	group.updateRoutes(func(t *routeTable) {
+		t.addRouteName(name, group.lastPaths[0])
+	})
+	return group.returnObj()
+}
+
+// updateRoutes applies fn to the route table the group registers its routes in.
+func (group *RouterGroup) updateRoutes(fn func(t *routeTable)) {
+	if group.table != nil {
+		assert1(!group.table.sealed, "the RouterGroup of Engine.Reload can not be used after Reload returned")
+		fn(group.table)
+		return
+	}
+	group.engine.updateRoutes(fn)
+}
+
+// Meta attaches metadata to the most recently registered routes of the group, so that
+// middleware can read the policy of the route with Context.RouteMeta.
+//
+//	router.GET("/admin/users", listUsers).Meta("scope", "admin").Meta("cost", 5)
+func (group *RouterGroup) Meta(key string, value any) IRoutes {
+	assert1(len(group.lastPaths) > 0, "there is no route to attach metadata to, register a route before calling Meta")
+	group.updateRoutes(func(t *routeTable) {
+		for _, path := range group.lastPaths {
+			t.setRouteMeta(group.host, group.lastMethods, path, key, value)
+		}
+	})
+	return group.returnObj()
+}
+
+// assertMethod panics if httpMethod is not a valid method token.
+func assertMethod(httpMethod string) {
+	if matched := regEnLetter.MatchString(httpMethod); !matched {
+		panic("http method " + httpMethod + " is not valid")
+	}
 }
 
 // Use adds middleware to the group, see example code in GitHub.
@@ -94,6 +419,10 @@ func (group *RouterGroup) Use(middleware ...HandlerFunc) IRoutes {
 	return group.returnObj()
 }
 
+func (group *RouterGroup) calculateAbsolutePath(relativePath string) string {
+	return joinPaths(group.basePath, relativePath)
+}
+
 func (group *RouterGroup) returnObj() IRoutes {
 	if group.root {
 		//如果是root,返回的是Engine
@@ -116,9 +445,13 @@ type IRoutes interface {
 	OPTIONS(string, ...HandlerFunc) IRoutes
 	HEAD(string, ...HandlerFunc) IRoutes
 	Match([]string, string, ...HandlerFunc) IRoutes
+	Mount(string, http.Handler) IRoutes
+	Name(string) IRoutes
+	Meta(string, any) IRoutes
 
 	StaticFile(string, string) IRoutes
 	StaticFileFS(string, string, http.FileSystem) IRoutes
 	Static(string, string) IRoutes
 	StaticFS(string, http.FileSystem) IRoutes
+	SPA(string, http.FileSystem, string) IRoutes
 }
@@ -0,0 +1,602 @@
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type header struct {
+	Key   string
+	Value string
+}
+
+// performRequest sends a request to r and returns the recorded response.
+func performRequest(r http.Handler, method, path string, headers ...header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for _, h := range headers {
+		req.Header.Add(h.Key, h.Value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRouteRedirectTrailingSlash(t *testing.T) {
+	router := New()
+	router.RedirectFixedPath = false
+	router.RedirectTrailingSlash = true
+	router.GET("/path", func(c *Context) {})
+	router.GET("/path2/", func(c *Context) {})
+	router.POST("/path3", func(c *Context) {})
+	router.PUT("/path4/", func(c *Context) {})
+	router.GET("/users/:id", func(c *Context) {})
+	router.GET("/src/*filepath", func(c *Context) {})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  []header
+		code     int
+		location string
+	}{
+		{"GET extra slash", http.MethodGet, "/path/", nil, http.StatusMovedPermanently, "/path"},
+		{"GET missing slash", http.MethodGet, "/path2", nil, http.StatusMovedPermanently, "/path2/"},
+		{"POST extra slash", http.MethodPost, "/path3/", nil, http.StatusTemporaryRedirect, "/path3"},
+		{"PUT missing slash", http.MethodPut, "/path4", nil, http.StatusTemporaryRedirect, "/path4/"},
+		{"param", http.MethodGet, "/users/42/", nil, http.StatusMovedPermanently, "/users/42"},
+		{"catch-all", http.MethodGet, "/src", nil, http.StatusMovedPermanently, "/src/"},
+		{"query kept", http.MethodGet, "/path/?a=1", nil, http.StatusMovedPermanently, "/path?a=1"},
+		{"forwarded prefix", http.MethodGet, "/path/", []header{{"X-Forwarded-Prefix", "/api"}}, http.StatusMovedPermanently, "/api/path"},
+		{"forwarded prefix with slash", http.MethodGet, "/path2", []header{{"X-Forwarded-Prefix", "/api/"}}, http.StatusMovedPermanently, "/api/path2/"},
+		{"forwarded prefix cleaned", http.MethodGet, "/path/", []header{{"X-Forwarded-Prefix", "../../api#?"}}, http.StatusMovedPermanently, "/api/path"},
+		{"forwarded prefix repeated slashes", http.MethodGet, "/path/", []header{{"X-Forwarded-Prefix", "/api//v1"}}, http.StatusMovedPermanently, "/api/v1/path"},
+		{"forwarded prefix unsafe chars", http.MethodGet, "/path/", []header{{"X-Forwarded-Prefix", "/api<script>"}}, http.StatusMovedPermanently, "/apiscript/path"},
+		{"root is never redirected", http.MethodGet, "/", nil, http.StatusNotFound, ""},
+		{"CONNECT is never redirected", http.MethodConnect, "/path/", nil, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performRequest(router, tt.method, tt.path, tt.headers...)
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d", w.Code, tt.code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("Location %q, want %q", location, tt.location)
+			}
+		})
+	}
+
+	router.RedirectTrailingSlash = false
+	if w := performRequest(router, http.MethodGet, "/path/"); w.Code != http.StatusNotFound {
+		t.Errorf("status %d without RedirectTrailingSlash, want 404", w.Code)
+	}
+}
+
+func TestRouteRedirectFixedPath(t *testing.T) {
+	router := New()
+	router.RedirectFixedPath = true
+	router.RedirectTrailingSlash = false
+
+	router.GET("/path", func(c *Context) {})
+	router.GET("/Path2", func(c *Context) {})
+	router.POST("/PATH3", func(c *Context) {})
+	router.POST("/Path4/", func(c *Context) {})
+	router.GET("/äpfel", func(c *Context) {})
+	router.GET("/Öl/π", func(c *Context) {})
+	router.GET("/users/:name/profile", func(c *Context) {})
+	router.GET("/files/*filepath", func(c *Context) {})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{"GET upper case", http.MethodGet, "/PATH", http.StatusMovedPermanently, "/path"},
+		{"GET lower case", http.MethodGet, "/path2", http.StatusMovedPermanently, "/Path2"},
+		{"POST", http.MethodPost, "/path3", http.StatusTemporaryRedirect, "/PATH3"},
+		{"POST with trailing slash", http.MethodPost, "/path4", http.StatusTemporaryRedirect, "/Path4/"},
+		{"dot segments", http.MethodGet, "/a/../PATH", http.StatusMovedPermanently, "/path"},
+		{"unicode", http.MethodGet, "/ÄPFEL", http.StatusMovedPermanently, "/%C3%A4pfel"},
+		{"unicode multi byte", http.MethodGet, "/öl/Π", http.StatusMovedPermanently, "/%C3%96l/%CF%80"},
+		{"param value kept", http.MethodGet, "/USERS/Bob/PROFILE", http.StatusMovedPermanently, "/users/Bob/profile"},
+		{"catch-all value kept", http.MethodGet, "/FILES/Docs/Readme.MD", http.StatusMovedPermanently, "/files/Docs/Readme.MD"},
+		{"no such route", http.MethodGet, "/nope", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performRequest(router, tt.method, tt.path)
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d", w.Code, tt.code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("Location %q, want %q", location, tt.location)
+			}
+		})
+	}
+}
+
+func TestRouteConstrainedSiblings(t *testing.T) {
+	fullPath := func(c *Context) { _, _ = c.Writer.WriteString(c.FullPath()) }
+	router := New()
+	router.GET("/at/:id{int}", fullPath)
+	router.GET("/at/:slug", fullPath)
+	router.GET("/at/new", fullPath)
+	router.GET("/n/:n{int}", fullPath)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/at/42", http.StatusOK, "/at/:id{int}"},
+		{"/at/hello", http.StatusOK, "/at/:slug"},
+		{"/at/new", http.StatusOK, "/at/new"},
+		{"/n/42", http.StatusOK, "/n/:n{int}"},
+		{"/n/abc", http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("GET %s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestRouteURLConstraint(t *testing.T) {
+	router := New()
+	router.GET("/users/:id{int}/posts/*rest", func(c *Context) {}).Name("user.posts")
+	router.GET(`/tags/:slug{[a-z-]+}/v\:1/:page`, func(c *Context) {}).Name("tag")
+
+	tests := []struct {
+		name   string
+		params []any
+		url    string
+	}{
+		{"user.posts", []any{"id", 42, "rest", "2024/hello"}, "/users/42/posts/2024/hello"},
+		{"user.posts", []any{"id", "abc", "rest", "x"}, ""},
+		{"tag", []any{"slug", "go-lang", "page", 2}, "/tags/go-lang/v:1/2"},
+		{"tag", []any{"slug", "Go", "page", 2}, ""},
+	}
+	for _, tt := range tests {
+		got, err := router.URL(tt.name, tt.params...)
+		if got != tt.url || (err == nil) != (tt.url != "") {
+			t.Errorf("URL(%q, %v): got %q, %v, want %q", tt.name, tt.params, got, err, tt.url)
+		}
+	}
+}
+
+func TestRouteLookup(t *testing.T) {
+	router := New()
+	router.GET("/users/:id/posts", func(c *Context) {})
+	router.GET("/files/:name", func(c *Context) {})
+	router.GET("/dir/", func(c *Context) {})
+
+	tests := []struct {
+		name       string
+		useRawPath bool
+		unescape   bool
+		path       string
+		fullPath   string
+		params     Params
+		tsr        bool
+		found      bool
+	}{
+		{"param", false, false, "/users/42/posts", "/users/:id/posts", Params{{Key: "id", Value: "42"}}, false, true},
+		{"escaped path", false, false, "/files/a%20b", "/files/:name", Params{{Key: "name", Value: "a b"}}, false, true},
+		{"escaped slash", false, false, "/files/a%2Fb", "", nil, false, false},
+		{"raw path", true, false, "/files/a%2Fb", "/files/:name", Params{{Key: "name", Value: "a%2Fb"}}, false, true},
+		{"raw path unescaped", true, true, "/files/a%2Fb", "/files/:name", Params{{Key: "name", Value: "a/b"}}, false, true},
+		{"trailing slash", false, false, "/dir", "", nil, true, false},
+		{"not found", false, false, "/missing", "", nil, false, false},
+	}
+	for _, tt := range tests {
+		router.UseRawPath = tt.useRawPath
+		router.UnescapePathValues = tt.unescape
+		fullPath, params, tsr, found := router.Lookup(http.MethodGet, tt.path)
+		if fullPath != tt.fullPath || tsr != tt.tsr || found != tt.found ||
+			(len(params) > 0 || len(tt.params) > 0) && !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("%s: got %q %v %v %v, want %q %v %v %v", tt.name,
+				fullPath, params, tsr, found, tt.fullPath, tt.params, tt.tsr, tt.found)
+		}
+	}
+
+	if _, _, _, found := router.Lookup(http.MethodPost, "/users/42/posts"); found {
+		t.Error("POST: found a route for a method without tree")
+	}
+}
+
+func TestRouteMountMetaAndName(t *testing.T) {
+	var scopes []any
+	router := New()
+	router.Use(func(c *Context) {
+		scope, _ := c.RouteMeta("scope")
+		scopes = append(scopes, scope)
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.Mount("/admin", h).Meta("scope", "admin").Name("admin")
+
+	for _, path := range []string{"/admin", "/admin/", "/admin/users"} {
+		scopes = scopes[:0]
+		performRequest(router, http.MethodPost, path)
+		if len(scopes) != 1 || scopes[0] != "admin" {
+			t.Errorf("POST %s: got scope %v, want admin", path, scopes)
+		}
+	}
+	if url, err := router.URL("admin"); err != nil || url != "/admin" {
+		t.Errorf("URL: got %q, %v", url, err)
+	}
+}
+
+func TestRouteNotFoundAndMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		noRoute  HandlerFunc
+		noMethod HandlerFunc
+		method   string
+		path     string
+		code     int
+		body     string
+		allow    string
+	}{
+		{"404", nil, nil, http.MethodGet, "/missing", http.StatusNotFound, "404 page not found", ""},
+		{"405", nil, nil, http.MethodPost, "/users", http.StatusMethodNotAllowed, "405 method not allowed", "GET, PUT"},
+		{"NoRoute writes a body", func(c *Context) { _, _ = c.Writer.WriteString("no route") }, nil,
+			http.MethodGet, "/missing", http.StatusNotFound, "no route", ""},
+		{"NoRoute changes the status", func(c *Context) { c.Status(http.StatusGone) }, nil,
+			http.MethodGet, "/missing", http.StatusGone, "", ""},
+		{"NoMethod writes a body", nil, func(c *Context) { _, _ = c.Writer.WriteString("no method") },
+			http.MethodPost, "/users", http.StatusMethodNotAllowed, "no method", "GET, PUT"},
+		// the NoRoute handlers do not run for 405, and the NoMethod ones not for 404
+		{"405 with NoRoute", func(c *Context) { _, _ = c.Writer.WriteString("no route") }, nil,
+			http.MethodPost, "/users", http.StatusMethodNotAllowed, "405 method not allowed", "GET, PUT"},
+		{"404 with NoMethod", nil, func(c *Context) { _, _ = c.Writer.WriteString("no method") },
+			http.MethodGet, "/missing", http.StatusNotFound, "404 page not found", ""},
+	}
+	for _, tt := range tests {
+		router := New()
+		router.HandleMethodNotAllowed = true
+		router.GET("/users", func(c *Context) {})
+		router.PUT("/users", func(c *Context) {})
+		if tt.noRoute != nil {
+			router.NoRoute(tt.noRoute)
+		}
+		if tt.noMethod != nil {
+			router.NoMethod(tt.noMethod)
+		}
+		// the global middleware added after NoRoute and NoMethod runs as well
+		router.Use(func(c *Context) { c.Header("X-Middleware", "1") })
+
+		w := performRequest(router, tt.method, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s: got %d %q Allow %q, want %d %q Allow %q", tt.name,
+				w.Code, w.Body.String(), w.Header().Get("Allow"), tt.code, tt.body, tt.allow)
+		}
+		if w.Header().Get("X-Middleware") != "1" {
+			t.Errorf("%s: the global middleware did not run", tt.name)
+		}
+	}
+
+	router := New()
+	router.GET("/users", func(c *Context) {})
+	if w := performRequest(router, http.MethodPost, "/users"); w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Errorf("without HandleMethodNotAllowed: got %d Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// OPTIONS is listed once, whether HandleOPTIONS or a route answers it
+	router.HandleMethodNotAllowed = true
+	router.HandleOPTIONS = true
+	router.OPTIONS("/options", func(c *Context) {})
+	router.GET("/options", func(c *Context) {})
+	for path, allow := range map[string]string{"/users": "GET, OPTIONS", "/options": "GET, OPTIONS"} {
+		if w := performRequest(router, http.MethodPost, path); w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != allow {
+			t.Errorf("HandleOPTIONS POST %s: got %d Allow %q, want %q", path, w.Code, w.Header().Get("Allow"), allow)
+		}
+	}
+}
+
+func TestRouteMount(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + " " + r.URL.RawPath))
+	})
+	router := New()
+	group := router.Group("/api", func(c *Context) { c.Header("X-Group", "api") })
+	group.Mount("/ext/", echo)
+
+	tests := []struct {
+		path  string
+		code  int
+		body  string
+		group string
+	}{
+		{"/api/ext/users", http.StatusOK, "/users ", "api"},
+		{"/api/ext", http.StatusOK, "/ ", "api"},
+		{"/api/ext/", http.StatusOK, "/ ", "api"},
+		{"/api/ext/a%2Fb", http.StatusOK, "/a/b /a%2Fb", "api"},
+		// /extra is not below the mount prefix
+		{"/api/extra", http.StatusNotFound, "404 page not found", ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("X-Group") != tt.group {
+			t.Errorf("GET %s: got %d %q group %q, want %d %q group %q", tt.path,
+				w.Code, w.Body.String(), w.Header().Get("X-Group"), tt.code, tt.body, tt.group)
+		}
+	}
+
+	// a root mount keeps the whole path
+	root := New()
+	root.Mount("/", echo)
+	for _, path := range []string{"/", "/other"} {
+		if w := performRequest(root, http.MethodGet, path); w.Body.String() != path+" " {
+			t.Errorf("root mount GET %s: got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouteWrap(t *testing.T) {
+	router := New()
+	router.Use(func(c *Context) { c.Header("X-Middleware", "1") })
+	router.GET("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("f " + r.URL.Path))
+	}))
+	router.GET("/h", WrapH(http.NotFoundHandler()))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/f", http.StatusCreated, "f /f"},
+		{"/h", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("X-Middleware") != "1" {
+			t.Errorf("GET %s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestRouteMethods(t *testing.T) {
+	router := New()
+	handler := func(c *Context) { _, _ = c.Writer.WriteString(c.Request.Method + " " + c.FullPath()) }
+	router.POST("/post", handler)
+	router.PUT("/put", handler)
+	router.PATCH("/patch", handler)
+	router.DELETE("/delete", handler)
+	router.HEAD("/head", handler)
+	router.OPTIONS("/options", handler)
+	router.Handle("PROPFIND", "/dav", handler)
+	router.Any("/any", handler)
+	router.Match([]string{http.MethodGet, "PURGE"}, "/match", handler)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPost, "/post", http.StatusOK},
+		{http.MethodGet, "/post", http.StatusNotFound},
+		{http.MethodPut, "/put", http.StatusOK},
+		{http.MethodPatch, "/patch", http.StatusOK},
+		{http.MethodDelete, "/delete", http.StatusOK},
+		{http.MethodHead, "/head", http.StatusOK},
+		{http.MethodOptions, "/options", http.StatusOK},
+		{"PROPFIND", "/dav", http.StatusOK},
+		{http.MethodGet, "/match", http.StatusOK},
+		{"PURGE", "/match", http.StatusOK},
+		{http.MethodPost, "/match", http.StatusNotFound},
+		// Any does not register custom methods
+		{"PURGE", "/any", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := performRequest(router, tt.method, tt.path)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.code)
+			continue
+		}
+		if want := tt.method + " " + tt.path; tt.code == http.StatusOK && tt.method != http.MethodHead && w.Body.String() != want {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, w.Body.String(), want)
+		}
+	}
+
+	for _, method := range anyMethods {
+		if w := performRequest(router, method, "/any"); w.Code != http.StatusOK {
+			t.Errorf("%s /any: got %d", method, w.Code)
+		}
+	}
+
+	for _, method := range []string{"", "get", "PUR GE", "GET/"} {
+		if recv := catchPanic(func() { router.Handle(method, "/invalid", handler) }); recv == nil {
+			t.Errorf("Handle(%q): no panic", method)
+		}
+		if recv := catchPanic(func() { router.Match([]string{method}, "/invalid", handler) }); recv == nil {
+			t.Errorf("Match(%q): no panic", method)
+		}
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	var calls []string
+	mw := func(name string) HandlerFunc {
+		return func(c *Context) { calls = append(calls, name) }
+	}
+	router := New()
+	router.Use(mw("engine"))
+	api := router.Group("/api", mw("api"))
+	v1 := api.Group("v1/", mw("v1"))
+	// the parent has spare capacity, the siblings must not share it
+	api.Handlers = slices.Grow(api.Handlers, 4)
+	users := api.Group("/users", mw("users"))
+	admin := api.Group("/admin", mw("admin"))
+	v1.GET("/status", mw("status"))
+	users.GET("", mw("list"))
+	admin.GET("/", mw("dashboard"))
+
+	tests := []struct {
+		group    *RouterGroup
+		basePath string
+	}{
+		{&router.RouterGroup, "/"},
+		{api, "/api"},
+		{v1, "/api/v1/"},
+		{users, "/api/users"},
+		{admin, "/api/admin"},
+	}
+	for _, tt := range tests {
+		if got := tt.group.BasePath(); got != tt.basePath {
+			t.Errorf("BasePath: got %q, want %q", got, tt.basePath)
+		}
+	}
+
+	requests := []struct {
+		path  string
+		calls []string
+	}{
+		{"/api/v1/status", []string{"engine", "api", "v1", "status"}},
+		{"/api/users", []string{"engine", "api", "users", "list"}},
+		{"/api/admin/", []string{"engine", "api", "admin", "dashboard"}},
+	}
+	for _, tt := range requests {
+		calls = nil
+		if w := performRequest(router, http.MethodGet, tt.path); w.Code != http.StatusOK || !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("GET %s: got %d %v, want %v", tt.path, w.Code, calls, tt.calls)
+		}
+	}
+}
+
+func routesListHandler(c *Context) {}
+
+func routesShowHandler(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	router := New()
+	router.GET("/users", routesListHandler)
+	router.GET("/users/:id", func(c *Context) {}, routesShowHandler).Meta("scope", "user")
+	router.POST("/users", routesListHandler)
+	router.Host("api.example.com").DELETE("/users/:id", routesShowHandler)
+
+	want := []RouteInfo{
+		{Method: http.MethodGet, Path: "/users", Handler: "gin2/gin.routesListHandler"},
+		{Method: http.MethodGet, Path: "/users/:id", Handler: "gin2/gin.routesShowHandler", Meta: map[string]any{"scope": "user"}},
+		{Method: http.MethodPost, Path: "/users", Handler: "gin2/gin.routesListHandler"},
+		{Host: "api.example.com", Method: http.MethodDelete, Path: "/users/:id", Handler: "gin2/gin.routesShowHandler"},
+	}
+	routes := router.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d: %v", len(routes), len(want), routes)
+	}
+	for _, w := range want {
+		i := slices.IndexFunc(routes, func(r RouteInfo) bool {
+			return r.Host == w.Host && r.Method == w.Method && r.Path == w.Path
+		})
+		if i < 0 {
+			t.Errorf("missing route %s %s%s", w.Method, w.Host, w.Path)
+			continue
+		}
+		r := routes[i]
+		if r.Handler != w.Handler || r.HandlerFunc == nil || !reflect.DeepEqual(r.Meta, w.Meta) {
+			t.Errorf("%s %s%s: got handler %q meta %v, want %q %v", w.Method, w.Host, w.Path, r.Handler, r.Meta, w.Handler, w.Meta)
+		}
+	}
+}
+
+func TestDebugPrintRouteFunc(t *testing.T) {
+	type route struct {
+		method, path, handler string
+		handlers              int
+	}
+	var routes []route
+	DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		routes = append(routes, route{httpMethod, absolutePath, handlerName, nuHandlers})
+	}
+	SetMode(DebugMode)
+	defer func() {
+		DebugPrintRouteFunc = nil
+		SetMode(TestMode)
+	}()
+
+	router := New()
+	router.Use(func(c *Context) {})
+	router.Group("/api").POST("/users", routesListHandler)
+
+	want := []route{{http.MethodPost, "/api/users", "gin2/gin.routesListHandler", 2}}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("got %v, want %v", routes, want)
+	}
+}
+
+func TestRouteMeta(t *testing.T) {
+	router := New()
+	router.Use(func(c *Context) {
+		scope, ok := c.RouteMeta("scope")
+		cost, _ := c.RouteMeta("cost")
+		c.Header("X-Meta", fmt.Sprintf("%v %v %v", scope, ok, cost))
+	})
+	handler := func(c *Context) {}
+	router.GET("/admin", handler).Meta("scope", "admin").Meta("cost", 5).Meta("cost", 10)
+	router.POST("/admin", handler)
+	router.Match([]string{http.MethodPut, http.MethodPatch}, "/users/:id", handler).Meta("scope", "user")
+	router.Group("/api").GET("/items", handler).Meta("cost", 1)
+	router.GET("/public", handler)
+
+	tests := []struct {
+		method string
+		path   string
+		meta   string
+	}{
+		{http.MethodGet, "/admin", "admin true 10"},
+		{http.MethodPost, "/admin", "<nil> false <nil>"},
+		{http.MethodPut, "/users/1", "user true <nil>"},
+		{http.MethodPatch, "/users/1", "user true <nil>"},
+		{http.MethodGet, "/api/items", "<nil> false 1"},
+		{http.MethodGet, "/public", "<nil> false <nil>"},
+		{http.MethodGet, "/missing", "<nil> false <nil>"},
+	}
+	for _, tt := range tests {
+		if got := performRequest(router, tt.method, tt.path).Header().Get("X-Meta"); got != tt.meta {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, got, tt.meta)
+		}
+	}
+
+	if recv := catchPanic(func() { New().Meta("scope", "admin") }); recv == nil {
+		t.Error("Meta without a route: no panic")
+	}
+}
+
+func TestRouteParams(t *testing.T) {
+	tests := []struct {
+		useRawPath bool
+		unescape   bool
+		path       string
+		params     string
+	}{
+		{false, false, "/users/john/files/css/app.css", "john /css/app.css"},
+		{false, false, "/users/john/files/", "john /"},
+		{false, false, "/users/j%20o/files/a%2Fb", "j o /a/b"},
+		{true, false, "/users/j%20o/files/a%2Fb", "j%20o /a%2Fb"},
+		{true, true, "/users/j%20o/files/a%2Fb", "j o /a/b"},
+		// without RawPath the escaped form is the path itself
+		{true, false, "/users/j%20o/files/a", "j o /a"},
+	}
+	for _, tt := range tests {
+		router := New()
+		router.UseRawPath = tt.useRawPath
+		router.UnescapePathValues = tt.unescape
+		router.GET("/users/:name/files/*filepath", func(c *Context) {
+			_, _ = c.Writer.WriteString(c.Param("name") + " " + c.Param("filepath"))
+		})
+		if w := performRequest(router, http.MethodGet, tt.path); w.Body.String() != tt.params {
+			t.Errorf("UseRawPath=%v UnescapePathValues=%v GET %s: got %d %q, want %q",
+				tt.useRawPath, tt.unescape, tt.path, w.Code, w.Body.String(), tt.params)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package gin
 
 import (
+	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -17,12 +21,93 @@ type methodTree struct {
 	root   *node
 }
 
-type methodTrees []methodTree
+// methodTrees holds the route tree of each method. The trees of the standard methods are
+// found by index and the ones of the custom methods in a map, so that finding the tree of
+// a request does not depend on the number of methods.
+// 标准方法通过固定下标的数组查找，自定义方法通过 map 查找，都是 O(1)
+type methodTrees struct {
+	// list holds every tree in registration order, which is the order of the iterations
+	list []methodTree
+	// std holds the trees of the standard methods, indexed by stdMethodIndex
+	std [stdMethodsCount]*node
+	// custom holds the trees of the other methods
+	custom map[string]*node
+}
+
+const stdMethodsCount = 9
+
+// stdMethodIndex returns the index of the standard method in methodTrees.std, or -1.
+func stdMethodIndex(method string) int {
+	switch method {
+	case http.MethodGet:
+		return 0
+	case http.MethodPost:
+		return 1
+	case http.MethodPut:
+		return 2
+	case http.MethodPatch:
+		return 3
+	case http.MethodDelete:
+		return 4
+	case http.MethodHead:
+		return 5
+	case http.MethodOptions:
+		return 6
+	case http.MethodConnect:
+		return 7
+	case http.MethodTrace:
+		return 8
+	}
+	return -1
+}
+
+// get returns the root node of the tree registered for method, or nil.
+func (trees *methodTrees) get(method string) *node {
+	if i := stdMethodIndex(method); i >= 0 {
+		return trees.std[i]
+	}
+	return trees.custom[method]
+}
+
+// add registers root as the tree of method, which must not have one yet.
+func (trees *methodTrees) add(method string, root *node) {
+	trees.list = append(trees.list, methodTree{method: method, root: root})
+	if i := stdMethodIndex(method); i >= 0 {
+		trees.std[i] = root
+		return
+	}
+	if trees.custom == nil {
+		trees.custom = make(map[string]*node)
+	}
+	trees.custom[method] = root
+}
+
+// clone returns a deep copy of the trees.
+func (trees *methodTrees) clone() methodTrees {
+	var cp methodTrees
+	cp.list = make([]methodTree, 0, len(trees.list))
+	for _, tree := range trees.list {
+		cp.add(tree.method, tree.root.clone())
+	}
+	return cp
+}
 
 // Param is a single URL parameter, consisting of a key and a value.
 type Param struct {
 	Key   string
 	Value string
+	// constraint is the constraint the value matched, nil for unconstrained params.
+	constraint *paramConstraint
+}
+
+// Typed returns the value converted by the route constraint, e.g. an int for /users/:id{int}.
+// It returns nil for unconstrained params and for regular expression constraints.
+// The value is converted on each call, the router only checks it, so that matching does not allocate.
+func (p Param) Typed() any {
+	if p.constraint == nil || p.constraint.convert == nil {
+		return nil
+	}
+	return p.constraint.convert(p.Value)
 }
 
 // Params is a Param-slice, as returned by the router.
@@ -30,6 +115,24 @@ type Param struct {
 // It is therefore safe to read values by the index.
 type Params []Param
 
+// Get returns the value of the first Param which key matches the given name and a boolean true.
+// If no matching Param is found, an empty string is returned and a boolean false .
+func (ps Params) Get(name string) (string, bool) {
+	for _, entry := range ps {
+		if entry.Key == name {
+			return entry.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName returns the value of the first Param which key matches the given name.
+// If no matching Param is found, an empty string is returned.
+func (ps Params) ByName(name string) (va string) {
+	va, _ = ps.Get(name)
+	return
+}
+
 type nodeType uint8
 
 type node struct {
@@ -41,12 +144,22 @@ type node struct {
 	children  []*node // child nodes, at most 1 :param style node at the end of the array
 	handlers  HandlersChain
 	fullPath  string
+	// meta is the metadata attached to the route of the node with RouterGroup.Meta.
+	// It is replaced, never changed in place, as it is shared by the copies of the route table.
+	meta map[string]any
+	// constraint restricts the values accepted by a :param node, nil if the param is unconstrained.
+	constraint *paramConstraint
 }
 
+// skippedNode is a wildcard node the walk may come back to: the wildcard child of a
+// node whose static children were tried first, or a param whose value may be extended.
 type skippedNode struct {
+	// path is the path left to match from node
 	path        string
 	node        *node
 	paramsCount int16
+	// end is the position the end of the param value is searched from when resuming
+	end int
 }
 
 // nodeValue holds return values of (*Node).getValue method
@@ -55,6 +168,467 @@ type nodeValue struct {
 	params   *Params
 	tsr      bool
 	fullPath string
+	meta     map[string]any
+}
+
+func longestCommonPrefix(a, b string) int {
+	i := 0
+	max_ := min(len(a), len(b))
+	for i < max_ && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// addChild will add a child node, keeping the wildcard children at the end.
+// A static child goes after the other static children, a constrained param
+// goes before an unconstrained one.
+func (n *node) addChild(child *node) {
+	if !n.wildChild || len(n.children) == 0 {
+		n.children = append(n.children, child)
+		return
+	}
+	pos := len(n.children)
+	if child.nType == static {
+		// indices already holds the first byte of child
+		pos = len(n.indices) - 1
+	} else if child.constraint != nil && n.children[pos-1].constraint == nil {
+		pos--
+	}
+	n.children = slices.Insert(n.children, pos, child)
+}
+
+// wildChildren returns the wildcard children of n, which follow its static children:
+// a catch-all, or params tried in order, the constrained ones first.
+func (n *node) wildChildren() []*node {
+	if !n.wildChild {
+		return nil
+	}
+	return n.children[len(n.indices):]
+}
+
+func countParams(path string) uint16 {
+	colons := strings.Count(path, ":")
+	stars := strings.Count(path, "*")
+	return safeUint16(colons + stars)
+}
+
+func countSections(path string) uint16 {
+	return safeUint16(strings.Count(path, "/"))
+}
+
+func safeUint16(n int) uint16 {
+	if n > 0xffff {
+		return 0xffff
+	}
+	return uint16(n)
+}
+
+// clone returns a deep copy of the subtree rooted at n, the handlers are shared.
+func (n *node) clone() *node {
+	cp := *n
+	if n.children != nil {
+		cp.children = make([]*node, len(n.children))
+		for i, child := range n.children {
+			cp.children[i] = child.clone()
+		}
+	}
+	return &cp
+}
+
+// accepts reports whether the param node n accepts value, according to its constraint.
+func (n *node) accepts(value string) bool {
+	if n.constraint == nil {
+		return true
+	}
+	return n.constraint.match(value)
+}
+
+// findRoute looks up the node registered for the route pattern path, wildcards are
+// compared literally. It returns the nodes from n to the one holding the handlers,
+// or nil if the pattern has no handlers.
+func (n *node) findRoute(path string, chain []*node) []*node {
+	if !strings.HasPrefix(path, n.path) {
+		return nil
+	}
+	path = path[len(n.path):]
+	chain = append(chain, n)
+	if path == "" && n.handlers != nil {
+		return chain
+	}
+	for _, child := range n.children {
+		if found := child.findRoute(path, chain); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// removeChild removes child from the children of n, keeping indices and wildChild in sync.
+func (n *node) removeChild(child *node) {
+	for i, c := range n.children {
+		if c != child {
+			continue
+		}
+		n.children = append(n.children[:i], n.children[i+1:]...)
+		if i < len(n.indices) {
+			n.indices = n.indices[:i] + n.indices[i+1:]
+		} else {
+			// the wildcard children are always at the end of the array
+			n.wildChild = len(n.children) > len(n.indices)
+		}
+		return
+	}
+}
+
+// Increments priority of the given child and reorders if necessary
+// 增加子节点的优先级，并在必要时重新排序，让访问频率更高的子节点排在前面
+func (n *node) incrementChildPrio(pos int) int {
+	cs := n.children
+	cs[pos].priority++
+	prio := cs[pos].priority
+
+	// Adjust position (move to front)
+	newPos := pos
+	for ; newPos > 0 && cs[newPos-1].priority < prio; newPos-- {
+		// Swap node positions
+		cs[newPos-1], cs[newPos] = cs[newPos], cs[newPos-1]
+	}
+
+	// Build new index char string
+	if newPos != pos {
+		n.indices = n.indices[:newPos] + // Unchanged prefix, might be empty
+			n.indices[pos:pos+1] + // The index char we move
+			n.indices[newPos:pos] + n.indices[pos+1:] // Rest without char at 'pos'
+	}
+
+	return newPos
+}
+
+// addRoute adds a node with the given handle to the path.
+// Not concurrency-safe!
+// 向路由树中添加一个带有处理函数的路径，非并发安全，只能在启动阶段调用
+func (n *node) addRoute(path string, handlers HandlersChain) {
+	fullPath := path
+	n.priority++
+
+	// Empty tree
+	if len(n.path) == 0 && len(n.children) == 0 {
+		n.insertChild(path, fullPath, handlers)
+		n.nType = root
+		return
+	}
+
+	parentFullPathIndex := 0
+
+walk:
+	for {
+		// Find the longest common prefix.
+		// This also implies that the common prefix contains no ':' or '*'
+		// since the existing key can't contain those chars.
+		i := longestCommonPrefix(path, n.path)
+
+		// Split edge
+		// 公共前缀比当前节点的 path 短，需要把当前节点拆分成两个节点
+		if i < len(n.path) {
+			child := node{
+				path:      n.path[i:],
+				wildChild: n.wildChild,
+				nType:     static,
+				indices:   n.indices,
+				children:  n.children,
+				handlers:  n.handlers,
+				priority:  n.priority - 1,
+				fullPath:  n.fullPath,
+				meta:      n.meta,
+			}
+
+			n.children = []*node{&child}
+			// []byte for proper unicode char conversion, see #65
+			n.indices = string([]byte{n.path[i]})
+			n.path = path[:i]
+			n.handlers = nil
+			n.meta = nil
+			n.wildChild = false
+			n.fullPath = fullPath[:parentFullPathIndex+i]
+		}
+
+		// Make new node a child of this node
+		if i < len(path) {
+			path = path[i:]
+			c := path[0]
+
+			// Check if a child with the next path byte exists
+			for i, max_ := 0, len(n.indices); i < max_; i++ {
+				if c == n.indices[i] {
+					parentFullPathIndex += len(n.path)
+					i = n.incrementChildPrio(i)
+					n = n.children[i]
+					continue walk
+				}
+			}
+
+			// Otherwise insert it
+			if c != ':' && c != '*' && n.nType != catchAll {
+				// []byte for proper unicode char conversion, see #65
+				n.indices += string([]byte{c})
+				child := &node{
+					fullPath: fullPath,
+				}
+				n.addChild(child)
+				n.incrementChildPrio(len(n.indices) - 1)
+				n = child
+			} else if n.wildChild {
+				// inserting a wildcard node, need to check if it conflicts with the existing wildcards.
+				// Check if the wildcard matches, comparing whole wildcards
+				// so that e.g. :name and :names conflict.
+				// Adding a child to a catchAll is not possible
+				wildcard, _, _ := findWildcard(path)
+				wildChildren := n.wildChildren()
+				for _, child := range wildChildren {
+					if wildcard == child.path && child.nType != catchAll {
+						n = child
+						n.priority++
+						continue walk
+					}
+				}
+
+				// Params may share a position as long as at most one of them is unconstrained,
+				// e.g. :id{int} and :slug, they are tried in order, the constrained ones first
+				// 同一位置可以有多个参数，只要最多一个没有约束，例如 :id{int} 和 :slug
+				if c == ':' && wildChildren[0].nType == param &&
+					(strings.IndexByte(wildcard, '{') > 0 || wildChildren[len(wildChildren)-1].constraint != nil) {
+					n.insertChild(path, fullPath, handlers)
+					return
+				}
+
+				// Wildcard conflict
+				n = wildChildren[len(wildChildren)-1]
+				n.priority++
+				pathSeg := path
+				if n.nType != catchAll {
+					pathSeg, _, _ = strings.Cut(pathSeg, "/")
+				}
+				prefix := fullPath[:strings.Index(fullPath, pathSeg)] + n.path
+				panic("'" + pathSeg +
+					"' in new path '" + fullPath +
+					"' conflicts with existing wildcard '" + n.path +
+					"' in existing prefix '" + prefix +
+					"'")
+			} else if n.nType == param {
+				panic("wildcards must be separated by a literal, has: '" +
+					n.path + path + "' in path '" + fullPath + "'")
+			}
+
+			n.insertChild(path, fullPath, handlers)
+			return
+		}
+
+		// Otherwise add handle to current node
+		if n.handlers != nil {
+			panic("handlers are already registered for path '" + fullPath + "'")
+		}
+		n.handlers = handlers
+		n.fullPath = fullPath
+		return
+	}
+}
+
+// Search for a wildcard and check the name for invalid characters.
+// The name of a :param ends at the first character which is not a letter, a digit
+// or '_', so that a literal may follow it in the same segment, e.g. /files/:name.:ext.
+// A *catch-all runs until the end of the segment.
+// Before the literals were allowed a name ran until the end of the segment, so
+// /users/:user-id was the param "user-id". To not turn such a name silently into
+// the param "user" followed by the literal "-id", a '-' followed by a letter, a
+// digit or '_' right after a name panics; a literal '-' may still be followed by
+// another param, e.g. /ranges/:from-:to.
+// Returns -1 as index, if no wildcard was found.
+func findWildcard(path string) (wildcard string, i int, valid bool) {
+	// Find start
+	escapeColon := false
+	for start, c := range []byte(path) {
+		if escapeColon {
+			escapeColon = false
+			if c == ':' {
+				continue
+			}
+			panic("invalid escape string in path '" + path + "'")
+		}
+		if c == '\\' {
+			escapeColon = true
+			continue
+		}
+		// A wildcard starts with ':' (param) or '*' (catch-all)
+		if c != ':' && c != '*' {
+			continue
+		}
+
+		// Find end and check for invalid characters
+		valid = true
+		end := start + 1
+		for end < len(path) && path[end] != '/' {
+			c := path[end]
+			if c == '{' {
+				// A constraint in braces, e.g. :id{int}, may contain any character
+				for depth := 0; end < len(path); end++ {
+					if path[end] == '{' {
+						depth++
+					} else if path[end] == '}' {
+						if depth--; depth == 0 {
+							end++
+							break
+						}
+					}
+				}
+				if path[start] == ':' {
+					break
+				}
+				continue
+			}
+			if path[start] == ':' && !isParamNameChar(c) {
+				if c == '-' && end+1 < len(path) && isParamNameChar(path[end+1]) {
+					literal, _, _ := strings.Cut(path[end:], "/")
+					panic("param name '" + path[start:end] + "' is followed by '" + literal + "' in path '" + path +
+						"', param names only contain letters, digits and '_', e.g. ':user_id'")
+				}
+				break
+			}
+			if c == ':' || c == '*' {
+				valid = false
+			}
+			end++
+		}
+
+		// Two wildcards must be separated by a literal
+		if end < len(path) && (path[end] == ':' || path[end] == '*') {
+			valid = false
+		}
+		return path[start:end], start, valid
+	}
+	return "", -1, false
+}
+
+// isParamNameChar reports whether c may be part of the name of a :param.
+func isParamNameChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (n *node) insertChild(path string, fullPath string, handlers HandlersChain) {
+	for {
+		// Find prefix until first wildcard
+		wildcard, i, valid := findWildcard(path)
+		if i < 0 { // No wildcard found
+			break
+		}
+
+		// The wildcard name must only contain one ':' or '*' character
+		// and must not be directly followed by another wildcard
+		if !valid {
+			panic("wildcards must be separated by a literal, has: '" +
+				wildcard + "' in path '" + fullPath + "'")
+		}
+
+		// check if the wildcard has a name
+		if name, _ := splitWildcard(wildcard, fullPath); name == "" {
+			panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
+		}
+		constraint := newParamConstraint(wildcard, fullPath)
+
+		if wildcard[0] == ':' { // param
+			if i > 0 {
+				// Insert prefix before the current wildcard
+				n.path = path[:i]
+				path = path[i:]
+			}
+
+			child := &node{
+				nType:      param,
+				path:       wildcard,
+				fullPath:   fullPath,
+				constraint: constraint,
+			}
+			n.addChild(child)
+			n.wildChild = true
+			n = child
+			n.priority++
+
+			// if the path doesn't end with the wildcard, then there
+			// will be another subpath starting with '/' or with the
+			// literal following the param in the same segment
+			if len(wildcard) < len(path) {
+				path = path[len(wildcard):]
+
+				child := &node{
+					priority: 1,
+					fullPath: fullPath,
+				}
+				// []byte for proper unicode char conversion, see #65
+				n.indices = string([]byte{path[0]})
+				n.addChild(child)
+				n = child
+				continue
+			}
+
+			// Otherwise we're done. Insert the handle in the new leaf
+			n.handlers = handlers
+			return
+		}
+
+		// catchAll
+		if i+len(wildcard) != len(path) {
+			panic("catch-all routes are only allowed at the end of the path in path '" + fullPath + "'")
+		}
+
+		if len(n.path) > 0 && n.path[len(n.path)-1] == '/' {
+			pathSeg := ""
+			if len(n.children) != 0 {
+				pathSeg, _, _ = strings.Cut(n.children[0].path, "/")
+			}
+			panic("catch-all wildcard '" + path +
+				"' in new path '" + fullPath +
+				"' conflicts with existing path segment '" + pathSeg +
+				"' in existing prefix '" + n.path + pathSeg +
+				"'")
+		}
+
+		// currently fixed width 1 for '/'
+		i--
+		if i < 0 || path[i] != '/' {
+			panic("no / before catch-all in path '" + fullPath + "'")
+		}
+
+		n.path = path[:i]
+
+		// First node: catchAll node with empty path
+		child := &node{
+			wildChild: true,
+			nType:     catchAll,
+			fullPath:  fullPath,
+		}
+
+		n.addChild(child)
+		n.indices = string('/')
+		n = child
+		n.priority++
+
+		// second node: node holding the variable
+		child = &node{
+			path:     path[i:],
+			nType:    catchAll,
+			handlers: handlers,
+			priority: 1,
+			fullPath: fullPath,
+		}
+		n.children = []*node{child}
+
+		return
+	}
+
+	// If no wildcard was found, simply insert the path and handle
+	n.path = path
+	n.handlers = handlers
+	n.fullPath = fullPath
 }
 
 /**
@@ -66,198 +640,234 @@ URL和tree绑定的响应关系，其实这种策略就是将URL映射到树形
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
+//
+// The static children of a node take precedence over its wildcard child, which is
+// only tried when the path matches no static route. A param followed by a literal
+// in the same segment, e.g. :name in /files/:name.:ext, takes the shortest value:
+// it ends at the first occurrence of the literal, and only if the rest of the path
+// does not match it is extended up to the next one. Both fall backs go through
+// skippedNodes, so no allocation is needed.
+// 静态子节点优先于通配符子节点；同一段内后面跟着字面量的参数先取最短的值，后面匹配失败时再回溯取更长的值
 func (n *node) getValue(path string, params *Params, skippedNodes *[]skippedNode, unescape bool) (value nodeValue) {
-	var globalParamsCount int16
+	var (
+		globalParamsCount int16
+		// wildcard is set when n is a wildcard child to match against path
+		wildcard bool
+		// from is the position the end of a param value is searched from
+		from int
+	)
+
+	// backtrack resumes the walk at the last valid skippedNode, it returns false if there is none
+	backtrack := func() bool {
+		for length := len(*skippedNodes); length > 0; length-- {
+			skipped := (*skippedNodes)[length-1]
+			*skippedNodes = (*skippedNodes)[:length-1]
+			if strings.HasSuffix(skipped.path, path) {
+				path = skipped.path
+				n = skipped.node
+				if value.params != nil {
+					*value.params = (*value.params)[:skipped.paramsCount]
+				}
+				globalParamsCount = skipped.paramsCount
+				wildcard, from = true, skipped.end
+				return true
+			}
+		}
+		return false
+	}
 
 walk: // Outer loop for walking the tree
 	for {
-		prefix := n.path
-		if len(path) > len(prefix) {
-			if path[:len(prefix)] == prefix {
-				path = path[len(prefix):]
+		if wildcard {
+			wildcard = false
+			globalParamsCount++
 
-				// Try all the non-wildcard children first by matching the indices
-				idxc := path[0]
-				for i, c := range []byte(n.indices) {
-					if c == idxc {
-						//  strings.HasPrefix(n.children[len(n.children)-1].path, ":") == n.wildChild
-						if n.wildChild {
-							index := len(*skippedNodes)
-							*skippedNodes = (*skippedNodes)[:index+1]
-							(*skippedNodes)[index] = skippedNode{
-								path: prefix + path,
-								node: &node{
-									path:      n.path,
-									wildChild: n.wildChild,
-									nType:     n.nType,
-									priority:  n.priority,
-									children:  n.children,
-									handlers:  n.handlers,
-									fullPath:  n.fullPath,
-								},
-								paramsCount: globalParamsCount,
-							}
-						}
+			switch n.nType {
+			case param:
+				// Find param end: either '/', the path end or, if a literal follows
+				// the param in the same segment, the first occurrence of the literal
+				end := from
+				for end < len(path) && path[end] != '/' &&
+					(end == 0 || strings.IndexByte(n.indices, path[end]) < 0) {
+					end++
+				}
+				if end < len(path) && path[end] != '/' {
+					// Come back with a longer value if the rest of the path does not match
+					*skippedNodes = append(*skippedNodes, skippedNode{
+						path:        path,
+						node:        n,
+						paramsCount: globalParamsCount - 1,
+						end:         end + 1,
+					})
+				}
 
-						n = n.children[i]
-						continue walk
+				val := path[:end]
+				if unescape {
+					if v, err := url.PathUnescape(val); err == nil {
+						val = v
 					}
 				}
 
-				if !n.wildChild {
-					// If the path at the end of the loop is not equal to '/' and the current node has no child nodes
-					// the current node needs to roll back to last valid skippedNode
-					if path != "/" {
-						for length := len(*skippedNodes); length > 0; length-- {
-							skippedNode := (*skippedNodes)[length-1]
-							*skippedNodes = (*skippedNodes)[:length-1]
-							if strings.HasSuffix(skippedNode.path, path) {
-								path = skippedNode.path
-								n = skippedNode.node
-								if value.params != nil {
-									*value.params = (*value.params)[:skippedNode.paramsCount]
-								}
-								globalParamsCount = skippedNode.paramsCount
-								continue walk
-							}
-						}
+				// Check the constraint, a mismatch falls through to a longer value,
+				// to the last valid skippedNode (a sibling route) or to not found
+				// 不满足约束时回溯到上一个 skippedNode，尝试更长的值或其他兄弟路由
+				if !n.accepts(val) {
+					if backtrack() {
+						continue walk
 					}
-
-					// Nothing found.
-					// We can recommend to redirect to the same URL without a
-					// trailing slash if a leaf exists for that path.
-					value.tsr = path == "/" && n.handlers != nil
 					return value
 				}
 
-				// Handle wildcard child, which is always at the end of the array
-				n = n.children[len(n.children)-1]
-				globalParamsCount++
-
-				switch n.nType {
-				case param:
-					// fix truncate the parameter
-					// tree_test.go  line: 204
-
-					// Find param end (either '/' or path end)
-					end := 0
-					for end < len(path) && path[end] != '/' {
-						end++
+				// Save param value
+				if params != nil {
+					// Preallocate capacity if necessary
+					if cap(*params) < int(globalParamsCount) {
+						newParams := make(Params, len(*params), globalParamsCount)
+						copy(newParams, *params)
+						*params = newParams
 					}
 
-					// Save param value
-					if params != nil {
-						// Preallocate capacity if necessary
-						if cap(*params) < int(globalParamsCount) {
-							newParams := make(Params, len(*params), globalParamsCount)
-							copy(newParams, *params)
-							*params = newParams
-						}
-
-						if value.params == nil {
-							value.params = params
-						}
-						// Expand slice within preallocated capacity
-						i := len(*value.params)
-						*value.params = (*value.params)[:i+1]
-						val := path[:end]
-						if unescape {
-							if v, err := url.QueryUnescape(val); err == nil {
-								val = v
-							}
-						}
-						(*value.params)[i] = Param{
-							Key:   n.path[1:],
-							Value: val,
-						}
+					if value.params == nil {
+						value.params = params
+					}
+					// Expand slice within preallocated capacity
+					i := len(*value.params)
+					*value.params = (*value.params)[:i+1]
+					key := n.path[1:]
+					if n.constraint != nil {
+						key = n.constraint.key
 					}
+					(*value.params)[i] = Param{
+						Key:        key,
+						Value:      val,
+						constraint: n.constraint,
+					}
+				}
 
-					// we need to go deeper!
-					if end < len(path) {
-						if len(n.children) > 0 {
+				// we need to go deeper!
+				if end < len(path) {
+					for i, c := range []byte(n.indices) {
+						if c == path[end] {
 							path = path[end:]
-							n = n.children[0]
+							n = n.children[i]
 							continue walk
 						}
-
-						// ... but we can't
-						value.tsr = len(path) == end+1
-						return value
 					}
 
-					if value.handlers = n.handlers; value.handlers != nil {
-						value.fullPath = n.fullPath
-						return value
-					}
-					if len(n.children) == 1 {
-						// No handle found. Check if a handle for this path + a
-						// trailing slash exists for TSR recommendation
-						n = n.children[0]
-						value.tsr = (n.path == "/" && n.handlers != nil) || (n.path == "" && n.indices == "/")
+					// ... but we can't
+					value.tsr = len(path) == end+1 && n.handlers != nil
+					if !value.tsr && backtrack() {
+						continue walk
 					}
 					return value
+				}
 
-				case catchAll:
-					// Save param value
-					if params != nil {
-						// Preallocate capacity if necessary
-						if cap(*params) < int(globalParamsCount) {
-							newParams := make(Params, len(*params), globalParamsCount)
-							copy(newParams, *params)
-							*params = newParams
-						}
+				if value.handlers = n.handlers; value.handlers != nil {
+					value.fullPath = n.fullPath
+					value.meta = n.meta
+					return value
+				}
+				if i := strings.IndexByte(n.indices, '/'); i >= 0 {
+					// No handle found. Check if a handle for this path + a
+					// trailing slash exists for TSR recommendation
+					child := n.children[i]
+					value.tsr = (child.path == "/" && child.handlers != nil) || (child.path == "" && child.indices == "/")
+				}
+				if !value.tsr && backtrack() {
+					continue walk
+				}
+				return value
 
-						if value.params == nil {
-							value.params = params
-						}
-						// Expand slice within preallocated capacity
-						i := len(*value.params)
-						*value.params = (*value.params)[:i+1]
-						val := path
-						if unescape {
-							if v, err := url.QueryUnescape(path); err == nil {
-								val = v
-							}
-						}
-						(*value.params)[i] = Param{
-							Key:   n.path[2:],
-							Value: val,
+			case catchAll:
+				// Save param value
+				if params != nil {
+					// Preallocate capacity if necessary
+					if cap(*params) < int(globalParamsCount) {
+						newParams := make(Params, len(*params), globalParamsCount)
+						copy(newParams, *params)
+						*params = newParams
+					}
+
+					if value.params == nil {
+						value.params = params
+					}
+					// Expand slice within preallocated capacity
+					i := len(*value.params)
+					*value.params = (*value.params)[:i+1]
+					val := path
+					if unescape {
+						if v, err := url.PathUnescape(path); err == nil {
+							val = v
 						}
 					}
+					(*value.params)[i] = Param{
+						Key:   n.path[2:],
+						Value: val,
+					}
+				}
+
+				value.handlers = n.handlers
+				value.fullPath = n.fullPath
+				value.meta = n.meta
+				return value
+
+			default:
+				panic("invalid node type")
+			}
+		}
+
+		prefix := n.path
+		if len(path) > len(prefix) {
+			if path[:len(prefix)] == prefix {
+				path = path[len(prefix):]
+
+				// Try all the non-wildcard children first by matching the indices
+				idxc := path[0]
+				for i, c := range []byte(n.indices) {
+					if c == idxc {
+						// Come back to the wildcard children if the static one leads nowhere
+						skipWildChildren(skippedNodes, n.wildChildren(), path, globalParamsCount)
+
+						n = n.children[i]
+						continue walk
+					}
+				}
+
+				if !n.wildChild {
+					// If the path at the end of the loop is not equal to '/' and the current node has no child nodes
+					// the current node needs to roll back to last valid skippedNode
+					if path != "/" && backtrack() {
+						continue walk
+					}
 
-					value.handlers = n.handlers
-					value.fullPath = n.fullPath
+					// Nothing found.
+					// We can recommend to redirect to the same URL without a
+					// trailing slash if a leaf exists for that path.
+					value.tsr = path == "/" && n.handlers != nil
 					return value
-
-				default:
-					panic("invalid node type")
 				}
+
+				// Handle the wildcard children, which are always at the end of the array,
+				// coming back to the next one if the first leads nowhere
+				wildChildren := n.wildChildren()
+				skipWildChildren(skippedNodes, wildChildren[1:], path, globalParamsCount)
+				n = wildChildren[0]
+				wildcard, from = true, 0
+				continue walk
 			}
 		}
 
 		if path == prefix {
 			// If the current path does not equal '/' and the node does not have a registered handle and the most recently matched node has a child node
 			// the current node needs to roll back to last valid skippedNode
-			if n.handlers == nil && path != "/" {
-				for length := len(*skippedNodes); length > 0; length-- {
-					skippedNode := (*skippedNodes)[length-1]
-					*skippedNodes = (*skippedNodes)[:length-1]
-					if strings.HasSuffix(skippedNode.path, path) {
-						path = skippedNode.path
-						n = skippedNode.node
-						if value.params != nil {
-							*value.params = (*value.params)[:skippedNode.paramsCount]
-						}
-						globalParamsCount = skippedNode.paramsCount
-						continue walk
-					}
-				}
-				//	n = latestNode.children[len(latestNode.children)-1]
+			if n.handlers == nil && path != "/" && backtrack() {
+				continue walk
 			}
 			// We should have reached the node containing the handle.
 			// Check if this node has a handle registered.
 			if value.handlers = n.handlers; value.handlers != nil {
 				value.fullPath = n.fullPath
+				value.meta = n.meta
 				return value
 			}
 
@@ -295,22 +905,269 @@ walk: // Outer loop for walking the tree
 				path == prefix[:len(prefix)-1] && n.handlers != nil)
 
 		// roll back to last valid skippedNode
-		if !value.tsr && path != "/" {
-			for length := len(*skippedNodes); length > 0; length-- {
-				skippedNode := (*skippedNodes)[length-1]
-				*skippedNodes = (*skippedNodes)[:length-1]
-				if strings.HasSuffix(skippedNode.path, path) {
-					path = skippedNode.path
-					n = skippedNode.node
-					if value.params != nil {
-						*value.params = (*value.params)[:skippedNode.paramsCount]
+		if !value.tsr && path != "/" && backtrack() {
+			continue walk
+		}
+
+		return value
+	}
+}
+
+// skipWildChildren pushes wildChildren to skippedNodes in reverse order,
+// so that backtracking tries them in order.
+func skipWildChildren(skippedNodes *[]skippedNode, wildChildren []*node, path string, paramsCount int16) {
+	for i := len(wildChildren) - 1; i >= 0; i-- {
+		*skippedNodes = append(*skippedNodes, skippedNode{
+			path:        path,
+			node:        wildChildren[i],
+			paramsCount: paramsCount,
+		})
+	}
+}
+
+// Makes a case-insensitive lookup of the given path and tries to find a handler.
+// It can optionally also fix trailing slashes.
+// It returns the case-corrected path and a bool indicating whether the lookup
+// was successful.
+// 大小写不敏感地查找路径，用于 RedirectFixedPath，例如 /FOO 可以修正为 /foo
+func (n *node) findCaseInsensitivePath(path string, fixTrailingSlash bool) ([]byte, bool) {
+	const stackBufSize = 128
+
+	// Use a static sized buffer on the stack in the common case.
+	// If the path is too long, allocate a buffer on the heap instead.
+	buf := make([]byte, 0, stackBufSize)
+	if length := len(path) + 1; length > stackBufSize {
+		buf = make([]byte, 0, length)
+	}
+
+	ciPath := n.findCaseInsensitivePathRec(
+		path,
+		buf,       // Preallocate enough memory for new path
+		[4]byte{}, // Empty rune buffer
+		fixTrailingSlash,
+	)
+
+	return ciPath, ciPath != nil
+}
+
+// Shift bytes in array by n bytes left
+func shiftNRuneBytes(rb [4]byte, n int) [4]byte {
+	switch n {
+	case 0:
+		return rb
+	case 1:
+		return [4]byte{rb[1], rb[2], rb[3], 0}
+	case 2:
+		return [4]byte{rb[2], rb[3]}
+	case 3:
+		return [4]byte{rb[3]}
+	default:
+		return [4]byte{}
+	}
+}
+
+// Recursive case-insensitive lookup function used by n.findCaseInsensitivePath
+func (n *node) findCaseInsensitivePathRec(path string, ciPath []byte, rb [4]byte, fixTrailingSlash bool) []byte {
+	npLen := len(n.path)
+
+walk: // Outer loop for walking the tree
+	for len(path) >= npLen && (npLen == 0 || strings.EqualFold(path[1:npLen], n.path[1:])) {
+		// Add common prefix to result
+		oldPath := path
+		path = path[npLen:]
+		ciPath = append(ciPath, n.path...)
+
+		if len(path) == 0 {
+			// We should have reached the node containing the handle.
+			// Check if this node has a handle registered.
+			if n.handlers != nil {
+				return ciPath
+			}
+
+			// No handle found.
+			// Try to fix the path by adding a trailing slash
+			if fixTrailingSlash {
+				for i, c := range []byte(n.indices) {
+					if c == '/' {
+						n = n.children[i]
+						if (len(n.path) == 1 && n.handlers != nil) ||
+							(n.nType == catchAll && n.children[0].handlers != nil) {
+							return append(ciPath, '/')
+						}
+						return nil
 					}
-					globalParamsCount = skippedNode.paramsCount
-					continue walk
 				}
 			}
+			return nil
 		}
 
-		return value
+		// If this node does not have a wildcard (param or catchAll) child,
+		// we can just look up the next child node and continue to walk down
+		// the tree
+		if !n.wildChild {
+			// Skip rune bytes already processed
+			rb = shiftNRuneBytes(rb, npLen)
+
+			if rb[0] != 0 {
+				// Old rune not finished
+				idxc := rb[0]
+				for i, c := range []byte(n.indices) {
+					if c == idxc {
+						// continue with child node
+						n = n.children[i]
+						npLen = len(n.path)
+						continue walk
+					}
+				}
+			} else {
+				// Process a new rune
+				var rv rune
+
+				// Find rune start
+				// Runes are up to 4 byte long,
+				// -4 would definitely be another rune
+				var off int
+				for max_ := min(npLen, 3); off < max_; off++ {
+					if i := npLen - off; utf8.RuneStart(oldPath[i]) {
+						// read rune from cached path
+						rv, _ = utf8.DecodeRuneInString(oldPath[i:])
+						break
+					}
+				}
+
+				// Calculate lowercase bytes of current rune
+				lo := unicode.ToLower(rv)
+				utf8.EncodeRune(rb[:], lo)
+
+				// Skip already processed bytes
+				rb = shiftNRuneBytes(rb, off)
+
+				idxc := rb[0]
+				for i, c := range []byte(n.indices) {
+					// Lowercase matches
+					if c == idxc {
+						// must use a recursive approach since both the
+						// uppercase byte and the lowercase byte might exist
+						// as an index
+						if out := n.children[i].findCaseInsensitivePathRec(
+							path, ciPath, rb, fixTrailingSlash,
+						); out != nil {
+							return out
+						}
+						break
+					}
+				}
+
+				// If we found no match, the same for the uppercase rune,
+				// if it differs
+				if up := unicode.ToUpper(rv); up != lo {
+					utf8.EncodeRune(rb[:], up)
+					rb = shiftNRuneBytes(rb, off)
+
+					idxc := rb[0]
+					for i, c := range []byte(n.indices) {
+						// Uppercase matches
+						if c == idxc {
+							// Continue with child node
+							n = n.children[i]
+							npLen = len(n.path)
+							continue walk
+						}
+					}
+				}
+			}
+
+			// Nothing found. We can recommend to redirect to the same URL
+			// without a trailing slash if a leaf exists for that path
+			if fixTrailingSlash && path == "/" && n.handlers != nil {
+				return ciPath
+			}
+			return nil
+		}
+
+		// Handle the wildcard children, which are always at the end of the array
+		for _, child := range n.wildChildren() {
+			switch child.nType {
+			case param:
+				if out := child.findCaseInsensitiveParamRec(path, ciPath, fixTrailingSlash); out != nil {
+					return out
+				}
+
+			case catchAll:
+				return append(ciPath, path...)
+
+			default:
+				panic("invalid node type")
+			}
+		}
+		return nil
 	}
+
+	// Nothing found.
+	// Try to fix the path by adding / removing a trailing slash
+	if fixTrailingSlash {
+		if path == "/" {
+			return ciPath
+		}
+		if len(path)+1 == npLen && n.path[len(path)] == '/' &&
+			strings.EqualFold(path[1:], n.path[1:len(path)]) && n.handlers != nil {
+			return append(ciPath, n.path...)
+		}
+	}
+	return nil
+}
+
+// findCaseInsensitiveParamRec is the part of findCaseInsensitivePathRec matching
+// the param node n, the next wildcard child is tried if it returns nil.
+func (n *node) findCaseInsensitiveParamRec(path string, ciPath []byte, fixTrailingSlash bool) []byte {
+	// Find param end (either '/' or path end), trying first the
+	// literals which follow the param in the same segment
+	end := 0
+	for end < len(path) && path[end] != '/' {
+		if i := strings.IndexByte(n.indices, path[end]); end > 0 && i >= 0 && n.accepts(path[:end]) {
+			if out := n.children[i].findCaseInsensitivePathRec(
+				path[end:], append(ciPath, path[:end]...), [4]byte{}, fixTrailingSlash,
+			); out != nil {
+				return out
+			}
+		}
+		end++
+	}
+
+	// A value rejected by the constraint can not be fixed
+	if !n.accepts(path[:end]) {
+		return nil
+	}
+
+	// Add param value to case insensitive path
+	ciPath = append(ciPath, path[:end]...)
+
+	// We need to go deeper!
+	if end < len(path) {
+		if i := strings.IndexByte(n.indices, '/'); i >= 0 {
+			// Continue with child node
+			return n.children[i].findCaseInsensitivePathRec(path[end:], ciPath, [4]byte{}, fixTrailingSlash)
+		}
+
+		// ... but we can't
+		if fixTrailingSlash && len(path) == end+1 && n.handlers != nil {
+			return ciPath
+		}
+		return nil
+	}
+
+	if n.handlers != nil {
+		return ciPath
+	}
+
+	if i := strings.IndexByte(n.indices, '/'); fixTrailingSlash && i >= 0 {
+		// No handle found. Check if a handle for this path + a
+		// trailing slash exists
+		n = n.children[i]
+		if n.path == "/" && n.handlers != nil {
+			return append(ciPath, '/')
+		}
+	}
+
+	return nil
 }
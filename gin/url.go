@@ -0,0 +1,135 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// ErrRouteNotFound is returned by Engine.URL when no route has been registered with the given name.
+var ErrRouteNotFound = errors.New("gin: route name not found")
+
+// funcMap returns the functions available to templates rendered by the engine:
+// Engine.FuncMap plus the built-in "url" func, unless FuncMap overrides it.
+//
+//	<a href="{{ url "user.posts" "id" .ID "rest" "latest" }}">posts</a>
+func (engine *Engine) funcMap() template.FuncMap {
+	funcMap := template.FuncMap{
+		"url": engine.URL,
+	}
+	for name, fn := range engine.FuncMap {
+		funcMap[name] = fn
+	}
+	return funcMap
+}
+
+// URL builds the escaped path of the route registered under name.
+// params are key/value pairs for the wildcards of the route, e.g.
+//
+//	router.GET("/users/:id/posts/*rest", handler).Name("user.posts")
+//	router.URL("user.posts", "id", 42, "rest", "2024/hello") // "/users/42/posts/2024/hello"
+//
+// An error is returned if a wildcard has no value, a value does not satisfy the
+// constraint of its wildcard, or a key matches no wildcard.
+// 根据路由名字反向生成 URL，参数缺失、多余或者不满足约束都会返回错误
+func (engine *Engine) URL(name string, params ...any) (string, error) {
+	route, ok := engine.routes.Load().routeNames[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("gin: odd number of params for route %q", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("gin: param key %v for route %q is not a string", params[i], name)
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+
+	return buildURL(name, route, values)
+}
+
+// buildURL substitutes the wildcards of the route path with values.
+func buildURL(name string, route namedRoute, values map[string]string) (string, error) {
+	pattern := route.path
+	var sb strings.Builder
+	sb.Grow(len(pattern))
+	used := make(map[string]struct{}, len(values))
+	params := 0
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern) && pattern[i+1] == ':':
+			// escaped colon, a literal ':' in the path
+			sb.WriteByte(':')
+			i++
+		case c == ':' || c == '*':
+			wildcard, _, _ := findWildcard(pattern[i:])
+			end := i + len(wildcard)
+			key, _ := splitWildcard(wildcard, pattern)
+			value, ok := values[key]
+			if !ok {
+				return "", fmt.Errorf("gin: missing param %q for route %q", key, name)
+			}
+			used[key] = struct{}{}
+			if c == ':' {
+				if constraint := route.constraints[params]; constraint != nil {
+					if !constraint.match(value) {
+						return "", fmt.Errorf("gin: param %q of route %q does not match {%s}: %q",
+							key, name, constraint.expr, value)
+					}
+				}
+				params++
+				sb.WriteString(url.PathEscape(value))
+			} else {
+				sb.WriteString(escapeCatchAll(sb.String(), value))
+			}
+			i = end - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	for key := range values {
+		if _, ok := used[key]; !ok {
+			return "", fmt.Errorf("gin: unknown param %q for route %q", key, name)
+		}
+	}
+	return sb.String(), nil
+}
+
+// escapeCatchAll escapes every segment of a catch-all value. The '/' in front of
+// the catch-all is part of its value, so a leading '/' is not duplicated.
+func escapeCatchAll(prefix, value string) string {
+	value = strings.TrimPrefix(value, "/")
+	segments := strings.Split(value, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	value = strings.Join(segments, "/")
+	if !strings.HasSuffix(prefix, "/") {
+		value = "/" + value
+	}
+	return value
+}
+
+// pathConstraints returns the constraint of each :param of path in order, nil for an unconstrained one.
+func pathConstraints(path string) (constraints []*paramConstraint) {
+	for rest := path; ; {
+		wildcard, i, _ := findWildcard(rest)
+		if i < 0 {
+			return constraints
+		}
+		if wildcard[0] == ':' {
+			constraints = append(constraints, newParamConstraint(wildcard, path))
+		}
+		rest = rest[i+len(wildcard):]
+	}
+}
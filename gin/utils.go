@@ -2,6 +2,13 @@ package gin
 
 import (
 	"encoding/xml"
+	"net/http"
+	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // H is a shortcut for map[string]any
@@ -29,8 +36,166 @@ func (h H) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	return e.EncodeToken(xml.EndElement{Name: start.Name})
 }
 
+// WrapF is a helper function for wrapping http.HandlerFunc and returns a Gin middleware.
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		f(c.Writer, c.Request)
+	}
+}
+
+// WrapH is a helper function for wrapping http.Handler and returns a Gin middleware.
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
 func assert1(guard bool, text string) {
 	if !guard {
 		panic(text)
 	}
 }
+
+func lastChar(str string) uint8 {
+	if str == "" {
+		panic("The length of the string can't be 0")
+	}
+	return str[len(str)-1]
+}
+
+// joinPaths joins absolutePath and relativePath, keeping the trailing slash of relativePath.
+// 拼接路径，path.Join 会去掉末尾的 /，这里需要把它补回来
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+
+	finalPath := path.Join(absolutePath, relativePath)
+	if lastChar(relativePath) == '/' && lastChar(finalPath) != '/' {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+func chooseData(custom, wildcard any) any {
+	if custom != nil {
+		return custom
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	panic("negotiation config is invalid")
+}
+
+// parseAccept splits the Accept header value into its media ranges.
+func parseAccept(acceptHeader string) []string {
+	out := make([]string, 0, strings.Count(acceptHeader, ",")+1)
+	for part := range strings.SplitSeq(acceptHeader, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// mediaRange is a media range of an Accept header, e.g. "text/*;q=0.8", or an offered format.
+type mediaRange struct {
+	typ     string
+	subtype string
+	// params are the "name=value" parameters before the q-value.
+	params []string
+	q      float64
+}
+
+// parseMediaRange parses a media range, ok is false if it is not a type/subtype pair.
+func parseMediaRange(s string) (r mediaRange, ok bool) {
+	mediaType, params, q := parseWeighted(s)
+	typ, subtype, found := strings.Cut(mediaType, "/")
+	typ, subtype = strings.TrimSpace(typ), strings.TrimSpace(subtype)
+	if !found || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return r, false
+	}
+	return mediaRange{typ: strings.ToLower(typ), subtype: strings.ToLower(subtype), params: params, q: q}, true
+}
+
+// parseWeighted splits an element of a header value weighted by q-values, e.g. "gzip;q=0.8"
+// or "text/html;level=1;q=0.5", into its value, the "name=value" parameters before the
+// q-value and the q-value, 1 by default. Accept and Accept-Encoding are both parsed with it.
+// 解析带 q 值的请求头元素，Accept 和 Accept-Encoding 共用
+func parseWeighted(element string) (value string, params []string, q float64) {
+	value, rest, _ := strings.Cut(element, ";")
+	value, q = strings.TrimSpace(value), 1
+	for param := range strings.SplitSeq(rest, ";") {
+		name, v, _ := strings.Cut(param, "=")
+		name, v = strings.ToLower(strings.TrimSpace(name)), strings.Trim(strings.TrimSpace(v), `"`)
+		if name == "" {
+			continue
+		}
+		// The weight ends the parameters
+		if name == "q" {
+			if w, err := strconv.ParseFloat(v, 64); err == nil && w >= 0 && w <= 1 {
+				q = w
+			}
+			break
+		}
+		params = append(params, name+"="+v)
+	}
+	return value, params, q
+}
+
+// match reports whether the media range r applies to offer. The higher the specificity,
+// the more specific r is: type and subtype first, then the number of parameters.
+func (r mediaRange) match(offer mediaRange) (specificity int, ok bool) {
+	switch {
+	case r.typ == "*":
+		specificity = 0
+	case r.typ != offer.typ:
+		return 0, false
+	case r.subtype == "*":
+		specificity = 1
+	case r.subtype != offer.subtype:
+		return 0, false
+	default:
+		specificity = 2
+	}
+	for _, p := range r.params {
+		if !slices.ContainsFunc(offer.params, func(o string) bool { return strings.EqualFold(o, p) }) {
+			return 0, false
+		}
+	}
+	return specificity<<8 | len(r.params), true
+}
+
+// negotiateFormat returns the offer with the highest q-value given by the accepted media
+// ranges, the first one on a tie, or "" if none is acceptable.
+// 内容协商：每个候选格式取最具体的匹配范围的 q 值，q 值最高者胜出，相同则按服务端给出的顺序
+func negotiateFormat(accepted, offered []string) string {
+	ranges := make([]mediaRange, 0, len(accepted))
+	for _, a := range accepted {
+		if r, ok := parseMediaRange(a); ok {
+			ranges = append(ranges, r)
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, o := range offered {
+		offer, ok := parseMediaRange(o)
+		if !ok {
+			continue
+		}
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s, ok := r.match(offer); ok && s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+package gin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWeighted(t *testing.T) {
+	tests := []struct {
+		element string
+		value   string
+		params  []string
+		q       float64
+	}{
+		{"gzip", "gzip", nil, 1},
+		{" br ; q=0.5 ", "br", nil, 0.5},
+		{"text/html;level=1;q=0.2;ext=x", "text/html", []string{"level=1"}, 0.2},
+		{`text/plain; Charset="utf-8"`, "text/plain", []string{"charset=utf-8"}, 1},
+		{"gzip;q=0", "gzip", nil, 0},
+		{"gzip;q=2", "gzip", nil, 1},
+		{"gzip;q=abc", "gzip", nil, 1},
+	}
+	for _, tt := range tests {
+		value, params, q := parseWeighted(tt.element)
+		if value != tt.value || !reflect.DeepEqual(params, tt.params) || q != tt.q {
+			t.Errorf("parseWeighted(%q): got %q %q %v, want %q %q %v", tt.element, value, params, q, tt.value, tt.params, tt.q)
+		}
+	}
+}
+
+func TestEncodingQuality(t *testing.T) {
+	tests := []struct {
+		header string
+		coding string
+		q      float64
+	}{
+		{"gzip, br;q=0.8", "br", 0.8},
+		{"GZIP", "gzip", 1},
+		{"*;q=0.3, br;q=0", "gzip", 0.3},
+		{"*;q=0.3, br;q=0", "br", 0},
+		{"identity", "gzip", 0},
+	}
+	for _, tt := range tests {
+		if q := encodingQuality(tt.header, tt.coding); q != tt.q {
+			t.Errorf("encodingQuality(%q, %q): got %v, want %v", tt.header, tt.coding, q, tt.q)
+		}
+	}
+}
+
+func TestAcceptsHTML(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"text/html,application/xhtml+xml,*/*;q=0.8", true},
+		{"Text/HTML;q=0.1", true},
+		{"text/html;q=0, */*", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsHTML(tt.accept); got != tt.want {
+			t.Errorf("acceptsHTML(%q): got %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestJoinPaths(t *testing.T) {
+	tests := []struct {
+		absolutePath string
+		relativePath string
+		want         string
+	}{
+		{"", "", ""},
+		{"/", "", "/"},
+		{"/a", "", "/a"},
+		{"/a/", "", "/a/"},
+		{"/a", "b", "/a/b"},
+		{"/a/", "b", "/a/b"},
+		{"/a", "/b/", "/a/b/"},
+		{"/a/", "/", "/a/"},
+		{"/a", "/", "/a/"},
+		{"/a", "./b/../c/", "/a/c/"},
+		{"/", "/", "/"},
+	}
+	for _, tt := range tests {
+		if got := joinPaths(tt.absolutePath, tt.relativePath); got != tt.want {
+			t.Errorf("joinPaths(%q, %q): got %q, want %q", tt.absolutePath, tt.relativePath, got, tt.want)
+		}
+	}
+}